@@ -0,0 +1,499 @@
+package zipcodes
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Edge links two zipcodes together with the distance between them in Kilometers
+type Edge struct {
+	From     string
+	To       string
+	Distance float64
+}
+
+// ZipCodeWithScore pairs a zipcode location with a score computed over the dataset
+type ZipCodeWithScore struct {
+	ZipCodeLocation
+	Score float64
+}
+
+// percentileDistance returns the smallest distance of the list that is greater
+// than or equal to the given fraction of all distances. The list is sorted in place.
+func percentileDistance(distances []float64, fraction float64) float64 {
+	sort.Float64s(distances)
+	index := int(math.Ceil(fraction*float64(len(distances)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return distances[index]
+}
+
+// RadiusContainingFraction returns the radius in Kilometers around the center
+// zipcode that contains the given fraction (between 0 and 1) of the members
+func (zc *Zipcodes) RadiusContainingFraction(center string, members []string, fraction float64) (float64, error) {
+	if fraction <= 0 || fraction > 1 {
+		return 0, fmt.Errorf("zipcodes: fraction must be between 0 and 1")
+	}
+	if len(members) == 0 {
+		return 0, fmt.Errorf("zipcodes: member list is empty")
+	}
+	location, errLoc := zc.lookupWithCoordinates(center)
+	if errLoc != nil {
+		return 0, errLoc
+	}
+
+	distances := make([]float64, 0, len(members))
+	for _, zipCode := range members {
+		member, errMember := zc.lookupWithCoordinates(zipCode)
+		if errMember != nil {
+			return 0, errMember
+		}
+		distances = append(distances, DistanceBetweenPoints(location.Lat, location.Lon, member.Lat, member.Lon, earthRadiusKm))
+	}
+
+	return percentileDistance(distances, fraction), nil
+}
+
+// RadiusForCoverage returns the radius in Kilometers around the center zipcode
+// that contains the given fraction (between 0 and 1) of the zipcodes of the
+// whole dataset, the center included, e.g. to zoom a map on where most of the
+// data is while ignoring distant outliers. Zipcodes without coordinates are
+// left out.
+func (zc *Zipcodes) RadiusForCoverage(centerZip string, fraction float64) (float64, error) {
+	if fraction <= 0 || fraction > 1 {
+		return 0, fmt.Errorf("zipcodes: fraction must be between 0 and 1")
+	}
+	location, errLoc := zc.lookupWithCoordinates(centerZip)
+	if errLoc != nil {
+		return 0, errLoc
+	}
+
+	distances := []float64{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			distances = append(distances, DistanceBetweenPoints(location.Lat, location.Lon, elm.Lat, elm.Lon, earthRadiusKm))
+		}
+		return true
+	})
+
+	return percentileDistance(distances, fraction), nil
+}
+
+// distanceMatrix returns the distance between every pair of locations
+func distanceMatrix(locations []ZipCodeLocation, earthRadius float64) [][]float64 {
+	matrix := make([][]float64, len(locations))
+	for i := range locations {
+		matrix[i] = make([]float64, len(locations))
+	}
+	for i := range locations {
+		for j := i + 1; j < len(locations); j++ {
+			distance := DistanceBetweenPoints(locations[i].Lat, locations[i].Lon, locations[j].Lat, locations[j].Lon, earthRadius)
+			matrix[i][j] = distance
+			matrix[j][i] = distance
+		}
+	}
+	return matrix
+}
+
+// EachPair calls fn for every unordered pair of the given zipcodes with the
+// distance between them in Kilometers, without building the distance matrix,
+// so memory stays linear in the number of zipcodes. Pairs are visited in the
+// order of the list (first with second, first with third, ... second with
+// third...) until fn returns false. Every zipcode is looked up before the
+// first call, so fn is never called for a list with a missing zipcode.
+func (zc *Zipcodes) EachPair(zipCodes []string, fn func(a, b string, km float64) bool) error {
+	locations, err := zc.locations(zipCodes)
+	if err != nil {
+		return err
+	}
+
+	for i := range locations {
+		for j := i + 1; j < len(locations); j++ {
+			distance := DistanceBetweenPoints(locations[i].Lat, locations[i].Lon, locations[j].Lat, locations[j].Lon, earthRadiusKm)
+			if !fn(zipCodes[i], zipCodes[j], distance) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// DistanceStats returns the minimum, maximum, mean and median of the distances
+// in Kilometers between every pair of the given zipcodes
+func (zc *Zipcodes) DistanceStats(zipCodes []string) (min, max, mean, median float64, err error) {
+	if len(zipCodes) < 2 {
+		return 0, 0, 0, 0, fmt.Errorf("zipcodes: at least two zipcodes are needed")
+	}
+	locations, err := zc.locations(zipCodes)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+
+	matrix := distanceMatrix(locations, earthRadiusKm)
+	distances := []float64{}
+	sum := 0.0
+	for i := range matrix {
+		for j := i + 1; j < len(matrix); j++ {
+			distances = append(distances, matrix[i][j])
+			sum += matrix[i][j]
+		}
+	}
+	sort.Float64s(distances)
+
+	count := len(distances)
+	median = distances[count/2]
+	if count%2 == 0 {
+		median = (distances[count/2-1] + distances[count/2]) / 2
+	}
+	mean = sum / float64(count)
+	return distances[0], distances[count-1], math.Round(mean*100) / 100, math.Round(median*100) / 100, nil
+}
+
+// PairProximityFraction returns the fraction of the pairs of the given
+// zipcodes that are closer than thresholdKm to each other, a compactness
+// metric to compare how clustered different sets are
+func (zc *Zipcodes) PairProximityFraction(zipCodes []string, thresholdKm float64) (float64, error) {
+	if len(zipCodes) < 2 {
+		return 0, fmt.Errorf("zipcodes: at least two zipcodes are needed")
+	}
+	locations, err := zc.locations(zipCodes)
+	if err != nil {
+		return 0, err
+	}
+
+	matrix := distanceMatrix(locations, earthRadiusKm)
+	near, pairs := 0, 0
+	for i := range matrix {
+		for j := i + 1; j < len(matrix); j++ {
+			pairs++
+			if matrix[i][j] < thresholdKm {
+				near++
+			}
+		}
+	}
+	return float64(near) / float64(pairs), nil
+}
+
+// Medoid returns the zipcode of the set with the smallest sum of distances in
+// Kilometers to all the others, together with that sum. Unlike a centroid,
+// the result is always one of the given zipcodes. Ties go to the lowest zipcode.
+func (zc *Zipcodes) Medoid(zipCodes []string) (string, float64, error) {
+	if len(zipCodes) == 0 {
+		return "", 0, fmt.Errorf("zipcodes: zipcode list is empty")
+	}
+	locations, err := zc.locations(zipCodes)
+	if err != nil {
+		return "", 0, err
+	}
+
+	matrix := distanceMatrix(locations, earthRadiusKm)
+	medoid := ""
+	bestSum := math.Inf(1)
+	for i, row := range matrix {
+		sum := 0.0
+		for _, distance := range row {
+			sum += distance
+		}
+		sum = math.Round(sum*100) / 100
+		if closer(sum, zc.keyOf(locations[i]), bestSum, medoid) {
+			medoid = zc.keyOf(locations[i])
+			bestSum = sum
+		}
+	}
+	return medoid, bestSum, nil
+}
+
+// OneCenter returns the candidate whose farthest customer is the closest, that
+// is the location minimizing the worst case distance in Kilometers to serve
+// every customer, together with that distance. Unlike the medoid, which
+// minimizes the sum of distances, it guarantees a maximum distance. Ties go to
+// the lowest zipcode.
+func (zc *Zipcodes) OneCenter(candidates, customers []string) (string, float64, error) {
+	if len(candidates) == 0 {
+		return "", 0, fmt.Errorf("zipcodes: candidate list is empty")
+	}
+	if len(customers) == 0 {
+		return "", 0, fmt.Errorf("zipcodes: customer list is empty")
+	}
+	sites, err := zc.locations(candidates)
+	if err != nil {
+		return "", 0, err
+	}
+	served, err := zc.locations(customers)
+	if err != nil {
+		return "", 0, err
+	}
+
+	center := ""
+	bestDistance := math.Inf(1)
+	for _, site := range sites {
+		farthest := 0.0
+		for _, customer := range served {
+			farthest = math.Max(farthest, DistanceBetweenPoints(site.Lat, site.Lon, customer.Lat, customer.Lon, earthRadiusKm))
+		}
+		if closer(farthest, zc.keyOf(site), bestDistance, center) {
+			center = zc.keyOf(site)
+			bestDistance = farthest
+		}
+	}
+	return center, bestDistance, nil
+}
+
+// MinimumSpanningTree returns the edges connecting all the given zipcodes with
+// the smallest total great-circle distance, e.g. to lay out a network between
+// facilities, together with that total in Kilometers. The tree is grown with
+// Prim's algorithm from the lowest zipcode, each edge going from a zipcode
+// already in the tree to the one it adds, in the order they are added.
+func (zc *Zipcodes) MinimumSpanningTree(zipCodes []string) ([]Edge, float64, error) {
+	if len(zipCodes) == 0 {
+		return nil, 0, fmt.Errorf("zipcodes: zipcode list is empty")
+	}
+	locations, err := zc.locations(zipCodes)
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		return zc.keyOf(locations[i]) < zc.keyOf(locations[j])
+	})
+
+	matrix := distanceMatrix(locations, earthRadiusKm)
+	inTree := make([]bool, len(locations))
+	// nearest[i] is the tree member closest to locations[i] and
+	// distances[i] the distance between them
+	nearest := make([]int, len(locations))
+	distances := make([]float64, len(locations))
+	for i := range distances {
+		distances[i] = math.Inf(1)
+	}
+	distances[0] = 0
+
+	edges := make([]Edge, 0, len(locations)-1)
+	total := 0.0
+	for range locations {
+		next := -1
+		for i := range locations {
+			if inTree[i] {
+				continue
+			}
+			if next == -1 || closer(distances[i], zc.keyOf(locations[i]), distances[next], zc.keyOf(locations[next])) {
+				next = i
+			}
+		}
+		inTree[next] = true
+		if next != 0 {
+			edges = append(edges, Edge{From: zc.keyOf(locations[nearest[next]]), To: zc.keyOf(locations[next]), Distance: distances[next]})
+			total += distances[next]
+		}
+		for i := range locations {
+			if !inTree[i] && matrix[next][i] < distances[i] {
+				distances[i] = matrix[next][i]
+				nearest[i] = next
+			}
+		}
+	}
+	return edges, math.Round(total*100) / 100, nil
+}
+
+// NearestNeighborRoute orders the stops for a run leaving from start by always
+// heading to the closest stop not visited yet, and returns that order, start
+// included, together with the total distance in Kilometers. It is a greedy
+// heuristic: the route is usually reasonable but not guaranteed to be the
+// shortest one. Ties go to the lowest zipcode.
+func (zc *Zipcodes) NearestNeighborRoute(start string, stops []string) ([]string, float64, error) {
+	locations, err := zc.locations(append([]string{start}, stops...))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matrix := distanceMatrix(locations, earthRadiusKm)
+	visited := make([]bool, len(locations))
+	visited[0] = true
+	route := []string{zc.keyOf(locations[0])}
+	total := 0.0
+	current := 0
+	for len(route) < len(locations) {
+		next := -1
+		for i := range locations {
+			if visited[i] {
+				continue
+			}
+			if next == -1 || closer(matrix[current][i], zc.keyOf(locations[i]), matrix[current][next], zc.keyOf(locations[next])) {
+				next = i
+			}
+		}
+		visited[next] = true
+		route = append(route, zc.keyOf(locations[next]))
+		total += matrix[current][next]
+		current = next
+	}
+	return route, math.Round(total*100) / 100, nil
+}
+
+// CentralityRanking ranks the zipcodes of the dataset by their average
+// distance in Kilometers to the others, the most central first, e.g. to pick
+// natural hubs. To keep it tractable on large datasets the average is estimated
+// over the same random sample of sampleSize zipcodes for every zipcode, drawn
+// with the given seed so the same seed always gives the same ranking. With a
+// sampleSize of at least the number of zipcodes minus one the average is
+// exact. Zipcodes without coordinates are left out and ties go to the lowest
+// zipcode.
+func (zc *Zipcodes) CentralityRanking(sampleSize int, seed int64) ([]ZipCodeWithScore, error) {
+	if sampleSize < 1 {
+		return nil, fmt.Errorf("zipcodes: sampleSize must be greater than zero")
+	}
+	candidates := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			candidates = append(candidates, elm)
+		}
+		return true
+	})
+	if len(candidates) < 2 {
+		return nil, fmt.Errorf("zipcodes: dataset needs at least two zipcodes with coordinates")
+	}
+	// the dataset iteration order is random, sort so a seeded source
+	// always draws the same sample
+	sort.Slice(candidates, func(i, j int) bool {
+		return zc.keyOf(candidates[i]) < zc.keyOf(candidates[j])
+	})
+
+	// one more than sampleSize so every zipcode keeps sampleSize others
+	// when it is part of the sample itself
+	sample := rand.New(rand.NewSource(seed)).Perm(len(candidates))
+	if len(sample) > sampleSize+1 {
+		sample = sample[:sampleSize+1]
+	}
+
+	ranking := make([]ZipCodeWithScore, 0, len(candidates))
+	for i, elm := range candidates {
+		total, count := 0.0, 0
+		for _, j := range sample {
+			if j == i || count == sampleSize {
+				continue
+			}
+			total += haversine(elm.Lat, elm.Lon, candidates[j].Lat, candidates[j].Lon, earthRadiusKm)
+			count++
+		}
+		ranking = append(ranking, ZipCodeWithScore{ZipCodeLocation: elm, Score: math.Round(total/float64(count)*100) / 100})
+	}
+
+	sort.Slice(ranking, func(i, j int) bool {
+		return closer(ranking[i].Score, zc.keyOf(ranking[i].ZipCodeLocation), ranking[j].Score, zc.keyOf(ranking[j].ZipCodeLocation))
+	})
+	return ranking, nil
+}
+
+// FormatDistanceTable returns a text table of the distances between every
+// pair of the given zipcodes in the unit, with the zipcodes as row and column
+// headers, e.g. for CLIs and debugging. Columns are right aligned and
+// distances are printed with the precision of the unit.
+func (zc *Zipcodes) FormatDistanceTable(zipCodes []string, unit Unit) (string, error) {
+	radius, errUnit := unit.earthRadius()
+	if errUnit != nil {
+		return "", errUnit
+	}
+	if len(zipCodes) == 0 {
+		return "", fmt.Errorf("zipcodes: zipcode list is empty")
+	}
+	locations, err := zc.locations(zipCodes)
+	if err != nil {
+		return "", err
+	}
+
+	precision := 2
+	if unit == Meters {
+		precision = 0
+	}
+	matrix := distanceMatrix(locations, radius)
+	rows := [][]string{append([]string{""}, zipCodes...)}
+	for i, zipCode := range zipCodes {
+		row := []string{zipCode}
+		for _, distance := range matrix[i] {
+			row = append(row, fmt.Sprintf("%.*f", precision, unit.round(distance)))
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for j, cell := range row {
+			if len(cell) > widths[j] {
+				widths[j] = len(cell)
+			}
+		}
+	}
+	var table strings.Builder
+	for _, row := range rows {
+		fmt.Fprintf(&table, "%-*s", widths[0], row[0])
+		for j := 1; j < len(row); j++ {
+			fmt.Fprintf(&table, "  %*s", widths[j], row[j])
+		}
+		table.WriteString("\n")
+	}
+	return table.String(), nil
+}
+
+// CompareHubs returns the total distance from each of two candidate hubs to
+// the same customers in the unit, e.g. to pick a warehouse location. The
+// difference between both totals is the distance saved by the closest hub.
+func (zc *Zipcodes) CompareHubs(hubA, hubB string, customers []string, unit Unit) (totalA, totalB float64, err error) {
+	radius, errUnit := unit.earthRadius()
+	if errUnit != nil {
+		return 0, 0, errUnit
+	}
+	if len(customers) == 0 {
+		return 0, 0, fmt.Errorf("zipcodes: customer list is empty")
+	}
+	hubs, err := zc.locations([]string{hubA, hubB})
+	if err != nil {
+		return 0, 0, err
+	}
+	sites, err := zc.locations(customers)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, site := range sites {
+		totalA += haversine(hubs[0].Lat, hubs[0].Lon, site.Lat, site.Lon, radius)
+		totalB += haversine(hubs[1].Lat, hubs[1].Lon, site.Lat, site.Lon, radius)
+	}
+	return unit.round(totalA), unit.round(totalB), nil
+}
+
+// StandardDistance returns the standard distance of the given zipcodes in the
+// unit, the root mean square of their distances to the centroid of the set,
+// which measures in one number how spread out they are. The centroid is the
+// same as the one of WeightedCentroid with every zipcode weighted 1.
+func (zc *Zipcodes) StandardDistance(zipCodes []string, unit Unit) (float64, error) {
+	radius, errUnit := unit.earthRadius()
+	if errUnit != nil {
+		return 0, errUnit
+	}
+	if len(zipCodes) == 0 {
+		return 0, fmt.Errorf("zipcodes: zipcode list is empty")
+	}
+	sites, err := zc.locations(zipCodes)
+	if err != nil {
+		return 0, err
+	}
+
+	weights := make([]float64, len(sites))
+	for i := range weights {
+		weights[i] = 1
+	}
+	lat, lon, err := sphericalCentroid(sites, weights)
+	if err != nil {
+		return 0, err
+	}
+
+	sumSquares := 0.0
+	for _, site := range sites {
+		distance := haversine(lat, lon, site.Lat, site.Lon, radius)
+		sumSquares += distance * distance
+	}
+	return unit.round(math.Sqrt(sumSquares / float64(len(sites)))), nil
+}
@@ -0,0 +1,657 @@
+package zipcodes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRadiusContainingFraction(t *testing.T) {
+	members := []string{"03058", "20457", "22525", "19053"}
+	cases := []struct {
+		Fraction       float64
+		ExpectedRadius float64
+	}{
+		{
+			0.25,
+			49.87,
+		},
+		{
+			0.75,
+			357.59,
+		},
+		{
+			1,
+			364.75,
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		radius, err := zipcodesDataset.RadiusContainingFraction("01945", members, c.Fraction)
+		if err != nil {
+			t.Errorf("Unexpected error while computing radius %v", err)
+		}
+		if radius != c.ExpectedRadius {
+			t.Errorf("Radius does not match. Expected %v, got %v", c.ExpectedRadius, radius)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		Center      string
+		Members     []string
+		Fraction    float64
+		ExpectedErr string
+	}{
+		{"01945", members, 0, "zipcodes: fraction must be between 0 and 1"},
+		{"01945", members, 1.5, "zipcodes: fraction must be between 0 and 1"},
+		{"01945", []string{}, 0.5, "zipcodes: member list is empty"},
+		{"XYZ", members, 0.5, "zipcodes: zipcode XYZ not found !"},
+		{"01945", []string{"03058", "11111"}, 0.5, "zipcodes: zipcode 11111 not found !"},
+	}
+	for _, c := range fail {
+		_, err := zipcodesDataset.RadiusContainingFraction(c.Center, c.Members, c.Fraction)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestRadiusForCoverage(t *testing.T) {
+	cases := []struct {
+		Fraction       float64
+		ExpectedRadius float64
+	}{
+		{0.1, 0},
+		{0.25, 7.43},
+		{0.5, 253.87},
+		{0.75, 369.28},
+		{1, 629.27},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		radius, err := zipcodesDataset.RadiusForCoverage("20457", c.Fraction)
+		if err != nil {
+			t.Errorf("Unexpected error while computing radius %v", err)
+		}
+		if radius != c.ExpectedRadius {
+			t.Errorf("Radius covering %v of the dataset does not match. Expected %v, got %v", c.Fraction, c.ExpectedRadius, radius)
+		}
+	}
+
+	blankDataset, err := New("datasets/blank_coordinates_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	radius, err := blankDataset.RadiusForCoverage("01945", 1)
+	if err != nil || radius != 49.87 {
+		t.Errorf("Zipcodes without coordinates should be left out. Got %v %v, want %v", radius, err, 49.87)
+	}
+
+	// Failing cases
+	fail := []struct {
+		Dataset     *Zipcodes
+		Center      string
+		Fraction    float64
+		ExpectedErr string
+	}{
+		{zipcodesDataset, "20457", 0, "zipcodes: fraction must be between 0 and 1"},
+		{zipcodesDataset, "20457", 1.5, "zipcodes: fraction must be between 0 and 1"},
+		{zipcodesDataset, "XYZ", 0.5, "zipcodes: zipcode XYZ not found !"},
+		{blankDataset, "96799", 0.5, "zipcodes: zipcode 96799 has no coordinates"},
+	}
+	for _, c := range fail {
+		_, err := c.Dataset.RadiusForCoverage(c.Center, c.Fraction)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestDistanceStats(t *testing.T) {
+	cases := []struct {
+		ZipCodes       []string
+		ExpectedMin    float64
+		ExpectedMax    float64
+		ExpectedMean   float64
+		ExpectedMedian float64
+	}{
+		{
+			[]string{"01945", "03058"},
+			49.87,
+			49.87,
+			49.87,
+			49.87,
+		},
+		{
+			[]string{"20457", "22525", "19053"},
+			7.43,
+			98.52,
+			66.92,
+			94.8,
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		min, max, mean, median, err := zipcodesDataset.DistanceStats(c.ZipCodes)
+		if err != nil {
+			t.Errorf("Unexpected error while computing stats %v", err)
+		}
+		if min != c.ExpectedMin || max != c.ExpectedMax || mean != c.ExpectedMean || median != c.ExpectedMedian {
+			t.Errorf("Stats do not match. Expected %v/%v/%v/%v, got %v/%v/%v/%v", c.ExpectedMin, c.ExpectedMax, c.ExpectedMean, c.ExpectedMedian, min, max, mean, median)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		ZipCodes    []string
+		ExpectedErr string
+	}{
+		{[]string{"01945"}, "zipcodes: at least two zipcodes are needed"},
+		{[]string{"01945", "11111", "22222"}, "zipcodes: zipcode 11111 not found !"},
+	}
+	for _, c := range fail {
+		_, _, _, _, err := zipcodesDataset.DistanceStats(c.ZipCodes)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestPairProximityFraction(t *testing.T) {
+	cases := []struct {
+		ZipCodes    []string
+		ThresholdKm float64
+		Expected    float64
+	}{
+		{[]string{"C1", "C2", "C3"}, 150, 2.0 / 3},
+		{[]string{"C1", "C2", "C3"}, 250, 1},
+		{[]string{"C1", "C2", "C3"}, 100.08, 0},
+		{[]string{"C1", "C2", "C3", "C4"}, 150, 2.0 / 6},
+	}
+	zipcodesDataset, err := New("datasets/chain_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		fraction, err := zipcodesDataset.PairProximityFraction(c.ZipCodes, c.ThresholdKm)
+		if err != nil {
+			t.Errorf("Unexpected error while computing proximity %v", err)
+		}
+		if fraction != c.Expected {
+			t.Errorf("Unexpected fraction of %v within %v. Got %v, want %v", c.ZipCodes, c.ThresholdKm, fraction, c.Expected)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.PairProximityFraction([]string{"C1"}, 150)
+	if err == nil || err.Error() != "zipcodes: at least two zipcodes are needed" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: at least two zipcodes are needed")
+	}
+	_, err = zipcodesDataset.PairProximityFraction([]string{"C1", "XYZ"}, 150)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestMedoid(t *testing.T) {
+	cases := []struct {
+		ZipCodes       []string
+		ExpectedMedoid string
+		ExpectedSum    float64
+	}{
+		{[]string{"01945"}, "01945", 0},
+		{[]string{"03058", "01945"}, "01945", 49.87},
+		{[]string{"20457", "22525", "19053"}, "20457", 102.23},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		medoid, sum, err := zipcodesDataset.Medoid(c.ZipCodes)
+		if err != nil {
+			t.Errorf("Unexpected error while computing medoid %v", err)
+		}
+		if medoid != c.ExpectedMedoid || sum != c.ExpectedSum {
+			t.Errorf("Unexpected medoid. Got %s/%v, want %s/%v", medoid, sum, c.ExpectedMedoid, c.ExpectedSum)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		ZipCodes    []string
+		ExpectedErr string
+	}{
+		{[]string{}, "zipcodes: zipcode list is empty"},
+		{[]string{"01945", "11111"}, "zipcodes: zipcode 11111 not found !"},
+	}
+	for _, c := range fail {
+		_, _, err := zipcodesDataset.Medoid(c.ZipCodes)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestOneCenter(t *testing.T) {
+	cases := []struct {
+		Candidates       []string
+		Customers        []string
+		ExpectedZipCode  string
+		ExpectedDistance float64
+	}{
+		{[]string{"C1", "C2", "C3"}, []string{"C1", "C3"}, "C2", 100.08},
+		{[]string{"C3", "C1"}, []string{"C1", "C2", "C3"}, "C1", 200.15},
+		{[]string{"C4", "C1"}, []string{"C4"}, "C4", 0},
+		{[]string{"C1"}, []string{"C1", "C2"}, "C1", 100.08},
+	}
+	zipcodesDataset, err := New("datasets/chain_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		zipCode, distance, err := zipcodesDataset.OneCenter(c.Candidates, c.Customers)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for the center %v", err)
+		}
+		if zipCode != c.ExpectedZipCode || distance != c.ExpectedDistance {
+			t.Errorf("Unexpected center of %v. Got %s %v, want %s %v", c.Customers, zipCode, distance, c.ExpectedZipCode, c.ExpectedDistance)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		Candidates  []string
+		Customers   []string
+		ExpectedErr string
+	}{
+		{[]string{}, []string{"C1"}, "zipcodes: candidate list is empty"},
+		{[]string{"C1"}, []string{}, "zipcodes: customer list is empty"},
+		{[]string{"C1", "XYZ"}, []string{"C1"}, "zipcodes: zipcode XYZ not found !"},
+		{[]string{"C1"}, []string{"C2", "11111"}, "zipcodes: zipcode 11111 not found !"},
+	}
+	for _, c := range fail {
+		_, _, err := zipcodesDataset.OneCenter(c.Candidates, c.Customers)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestMinimumSpanningTree(t *testing.T) {
+	cases := []struct {
+		Dataset       string
+		ZipCodes      []string
+		ExpectedEdges []Edge
+		ExpectedTotal float64
+	}{
+		{
+			"datasets/valid_dataset.txt",
+			[]string{"01945"},
+			[]Edge{},
+			0,
+		},
+		{
+			"datasets/valid_dataset.txt",
+			[]string{"20457", "22525", "19053", "34134"},
+			[]Edge{{"19053", "20457", 94.8}, {"20457", "22525", 7.43}, {"20457", "34134", 253.87}},
+			356.1,
+		},
+		{
+			"datasets/route_dataset.txt",
+			[]string{"R3", "R1", "P1", "R2", "P3"},
+			[]Edge{{"P1", "R3", 157.23}, {"P1", "R1", 157.25}, {"R1", "P3", 111.19}, {"P1", "R2", 157.25}},
+			582.92,
+		},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		edges, total, err := zipcodesDataset.MinimumSpanningTree(c.ZipCodes)
+		if err != nil {
+			t.Errorf("Unexpected error while computing the minimum spanning tree %v", err)
+		}
+		if reflect.DeepEqual(edges, c.ExpectedEdges) != true || total != c.ExpectedTotal {
+			t.Errorf("Unexpected minimum spanning tree. Got %v/%v, want %v/%v", edges, total, c.ExpectedEdges, c.ExpectedTotal)
+		}
+	}
+
+	// Failing cases
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	fail := []struct {
+		ZipCodes    []string
+		ExpectedErr string
+	}{
+		{[]string{}, "zipcodes: zipcode list is empty"},
+		{[]string{"01945", "11111"}, "zipcodes: zipcode 11111 not found !"},
+	}
+	for _, c := range fail {
+		_, _, err := zipcodesDataset.MinimumSpanningTree(c.ZipCodes)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestNearestNeighborRoute(t *testing.T) {
+	cases := []struct {
+		Dataset       string
+		Start         string
+		Stops         []string
+		ExpectedRoute []string
+		ExpectedTotal float64
+	}{
+		{
+			"datasets/valid_dataset.txt",
+			"01945",
+			[]string{"20457", "03058", "34134", "19053", "22525"},
+			[]string{"01945", "03058", "19053", "20457", "22525", "34134"},
+			712.28,
+		},
+		{
+			"datasets/valid_dataset.txt",
+			"01945",
+			[]string{},
+			[]string{"01945"},
+			0,
+		},
+		{
+			// greedy: the closest stop P3 is visited first, even if it is
+			// in the opposite direction of all the others
+			"datasets/route_dataset.txt",
+			"R1",
+			[]string{"R3", "P2", "R2", "P3"},
+			[]string{"R1", "P3", "R2", "P2", "R3"},
+			759.25,
+		},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		route, total, err := zipcodesDataset.NearestNeighborRoute(c.Start, c.Stops)
+		if err != nil {
+			t.Errorf("Unexpected error while computing the route %v", err)
+		}
+		if reflect.DeepEqual(route, c.ExpectedRoute) != true || total != c.ExpectedTotal {
+			t.Errorf("Unexpected route. Got %v/%v, want %v/%v", route, total, c.ExpectedRoute, c.ExpectedTotal)
+		}
+	}
+
+	// Failing cases
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	fail := []struct {
+		Start       string
+		Stops       []string
+		ExpectedErr string
+	}{
+		{"11111", []string{"01945"}, "zipcodes: zipcode 11111 not found !"},
+		{"01945", []string{"03058", "22222"}, "zipcodes: zipcode 22222 not found !"},
+	}
+	for _, c := range fail {
+		_, _, err := zipcodesDataset.NearestNeighborRoute(c.Start, c.Stops)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestCentralityRanking(t *testing.T) {
+	zipcodesDataset, err := New("datasets/chain_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	// the sample covers every other zipcode, so the averages are exact
+	expected := []ZipCodeWithScore{
+		{ZipCodeLocation: zipcodesDataset.DatasetList["C2"], Score: 566.7},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["C3"], Score: 578.42},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["C1"], Score: 622.92},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["C4"], Score: 1501.17},
+	}
+	for _, seed := range []int64{1, 2} {
+		ranking, err := zipcodesDataset.CentralityRanking(10, seed)
+		if err != nil {
+			t.Errorf("Unexpected error while ranking zipcodes %v", err)
+		}
+		if reflect.DeepEqual(ranking, expected) != true {
+			t.Errorf("CentralityRanking returned an unexpected ranking. Got %v, want %v", ranking, expected)
+		}
+	}
+
+	zipcodesDataset, err = New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	first, _ := zipcodesDataset.CentralityRanking(3, 7)
+	second, _ := zipcodesDataset.CentralityRanking(3, 7)
+	if len(first) != 8 || reflect.DeepEqual(first, second) != true {
+		t.Errorf("CentralityRanking is not reproducible with the same seed. Got %v and %v", first, second)
+	}
+	if first[0].ZipCode != "19053" || first[0].Score != 228.74 {
+		t.Errorf("Unexpected most central zipcode. Got %v, want 19053 228.74", first[0])
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.CentralityRanking(0, 1)
+	if err == nil || err.Error() != "zipcodes: sampleSize must be greater than zero" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: sampleSize must be greater than zero")
+	}
+	single := NewFromSource(sliceSource{zipcodesDataset.DatasetList["01945"]})
+	_, err = single.CentralityRanking(3, 1)
+	if err == nil || err.Error() != "zipcodes: dataset needs at least two zipcodes with coordinates" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: dataset needs at least two zipcodes with coordinates")
+	}
+}
+
+func TestFormatDistanceTable(t *testing.T) {
+	cases := []struct {
+		ZipCodes []string
+		Unit     Unit
+		Expected string
+	}{
+		{
+			[]string{"01945", "03058", "20457"},
+			Kilometers,
+			"        01945   03058   20457\n" +
+				"01945    0.00   49.87  357.59\n" +
+				"03058   49.87    0.00  369.28\n" +
+				"20457  357.59  369.28    0.00\n",
+		},
+		{
+			[]string{"01945", "03058"},
+			Meters,
+			"       01945  03058\n" +
+				"01945      0  49866\n" +
+				"03058  49866      0\n",
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		table, err := zipcodesDataset.FormatDistanceTable(c.ZipCodes, c.Unit)
+		if err != nil {
+			t.Errorf("Unexpected error while formatting the distance table %v", err)
+		}
+		if table != c.Expected {
+			t.Errorf("Unexpected distance table in %v. Got\n%s\nwant\n%s", c.Unit, table, c.Expected)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		ZipCodes    []string
+		Unit        Unit
+		ExpectedErr string
+	}{
+		{[]string{}, Kilometers, "zipcodes: zipcode list is empty"},
+		{[]string{"01945", "XYZ"}, Kilometers, "zipcodes: zipcode XYZ not found !"},
+		{[]string{"01945"}, Unit(42), "zipcodes: unknown distance unit Unit(42)"},
+	}
+	for _, c := range fail {
+		_, err := zipcodesDataset.FormatDistanceTable(c.ZipCodes, c.Unit)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestCompareHubs(t *testing.T) {
+	customers := []string{"01945", "03058", "22525", "19053"}
+	cases := []struct {
+		Unit           Unit
+		ExpectedTotalA float64
+		ExpectedTotalB float64
+	}{
+		{Kilometers, 829.11, 1213.04},
+		{Miles, 515.08, 753.61},
+		{Meters, 829106, 1213043},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		totalA, totalB, err := zipcodesDataset.CompareHubs("20457", "34134", customers, c.Unit)
+		if err != nil {
+			t.Errorf("Unexpected error while comparing hubs %v", err)
+		}
+		if totalA != c.ExpectedTotalA || totalB != c.ExpectedTotalB {
+			t.Errorf("Unexpected totals in %v. Got %v/%v, want %v/%v", c.Unit, totalA, totalB, c.ExpectedTotalA, c.ExpectedTotalB)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		HubA, HubB  string
+		Customers   []string
+		Unit        Unit
+		ExpectedErr string
+	}{
+		{"20457", "34134", []string{}, Kilometers, "zipcodes: customer list is empty"},
+		{"XYZ", "34134", customers, Kilometers, "zipcodes: zipcode XYZ not found !"},
+		{"20457", "XYZ", customers, Kilometers, "zipcodes: zipcode XYZ not found !"},
+		{"20457", "34134", []string{"01945", "11111", "22222"}, Kilometers, "zipcodes: zipcode 11111 not found !"},
+		{"20457", "34134", customers, Unit(42), "zipcodes: unknown distance unit Unit(42)"},
+	}
+	for _, c := range fail {
+		_, _, err := zipcodesDataset.CompareHubs(c.HubA, c.HubB, c.Customers, c.Unit)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestStandardDistance(t *testing.T) {
+	cases := []struct {
+		ZipCodes []string
+		Unit     Unit
+		Expected float64
+	}{
+		{[]string{"20457", "22525"}, Kilometers, 3.72},
+		{[]string{"20457", "22525"}, Meters, 3717},
+		{[]string{"01945", "03058", "22525", "19053"}, Kilometers, 170.83},
+		{[]string{"01945", "03058", "22525", "19053"}, Miles, 106.13},
+		{[]string{"20457"}, Kilometers, 0},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		distance, err := zipcodesDataset.StandardDistance(c.ZipCodes, c.Unit)
+		if err != nil {
+			t.Errorf("Unexpected error while computing standard distance %v", err)
+		}
+		if distance != c.Expected {
+			t.Errorf("Unexpected standard distance of %v in %v. Got %v, want %v", c.ZipCodes, c.Unit, distance, c.Expected)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		ZipCodes    []string
+		Unit        Unit
+		ExpectedErr string
+	}{
+		{[]string{}, Kilometers, "zipcodes: zipcode list is empty"},
+		{[]string{"20457", "11111"}, Kilometers, "zipcodes: zipcode 11111 not found !"},
+		{[]string{"20457"}, Unit(42), "zipcodes: unknown distance unit Unit(42)"},
+	}
+	for _, c := range fail {
+		_, err := zipcodesDataset.StandardDistance(c.ZipCodes, c.Unit)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestEachPair(t *testing.T) {
+	type pair struct {
+		A, B string
+		Km   float64
+	}
+	zipCodes := []string{"20457", "22525", "19053"}
+	cases := []struct {
+		Limit    int
+		Expected []pair
+	}{
+		{10, []pair{{"20457", "22525", 7.43}, {"20457", "19053", 94.8}, {"22525", "19053", 98.52}}},
+		{2, []pair{{"20457", "22525", 7.43}, {"20457", "19053", 94.8}}},
+		{1, []pair{{"20457", "22525", 7.43}}},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		pairs := []pair{}
+		err := zipcodesDataset.EachPair(zipCodes, func(a, b string, km float64) bool {
+			pairs = append(pairs, pair{a, b, km})
+			return len(pairs) < c.Limit
+		})
+		if err != nil {
+			t.Errorf("Unexpected error while iterating pairs %v", err)
+		}
+		if reflect.DeepEqual(pairs, c.Expected) != true {
+			t.Errorf("EachPair visited unexpected pairs. Got %v, want %v", pairs, c.Expected)
+		}
+	}
+
+	calls := 0
+	if err := zipcodesDataset.EachPair([]string{"20457"}, func(a, b string, km float64) bool {
+		calls++
+		return true
+	}); err != nil || calls != 0 {
+		t.Errorf("EachPair should not visit any pair of a single zipcode. Got %d calls %v", calls, err)
+	}
+
+	// Failing case
+	calls = 0
+	err = zipcodesDataset.EachPair([]string{"20457", "22525", "11111"}, func(a, b string, km float64) bool {
+		calls++
+		return true
+	})
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" || calls != 0 {
+		t.Errorf("Unexpected error after %d calls. Got %v, want %s", calls, err, "zipcodes: zipcode 11111 not found !")
+	}
+}
@@ -0,0 +1,89 @@
+package zipcodes
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DBSCAN groups the zipcodes into density based clusters: a zipcode with at
+// least minPoints zipcodes within epsKm, itself included, is a core zipcode,
+// and every zipcode within epsKm of a core one joins its cluster. Zipcodes
+// reached by no core zipcode are returned as noise. Zipcodes are visited by
+// zipcode so the result is stable, each cluster is sorted by zipcode and the
+// clusters are ordered by their first zipcode. Zipcodes without coordinates
+// are left out of both.
+func (zc *Zipcodes) DBSCAN(epsKm float64, minPoints int) (clusters [][]string, noise []string, err error) {
+	if epsKm <= 0 {
+		return nil, nil, fmt.Errorf("zipcodes: eps must be greater than zero")
+	}
+	if minPoints < 1 {
+		return nil, nil, fmt.Errorf("zipcodes: minPoints must be greater than zero")
+	}
+
+	locations := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			locations = append(locations, elm)
+		}
+		return true
+	})
+	sort.Slice(locations, func(i, j int) bool {
+		return zc.keyOf(locations[i]) < zc.keyOf(locations[j])
+	})
+
+	const unvisited, noiseLabel = 0, -1
+	labels := make(map[string]int)
+	neighborsOf := func(location ZipCodeLocation) []string {
+		return zc.FindZipcodesWithinRadius(&location, epsKm, earthRadiusKm)
+	}
+
+	clusters = [][]string{}
+	for _, location := range locations {
+		if labels[zc.keyOf(location)] != unvisited {
+			continue
+		}
+		neighbors := neighborsOf(location)
+		if len(neighbors)+1 < minPoints {
+			labels[zc.keyOf(location)] = noiseLabel
+			continue
+		}
+
+		cluster := len(clusters) + 1
+		labels[zc.keyOf(location)] = cluster
+		members := []string{zc.keyOf(location)}
+		queue := neighbors
+		for len(queue) > 0 {
+			zipCode := queue[0]
+			queue = queue[1:]
+			switch labels[zipCode] {
+			case noiseLabel:
+				// a border zipcode, part of the cluster but not expanding it
+				labels[zipCode] = cluster
+				members = append(members, zipCode)
+				continue
+			case unvisited:
+				labels[zipCode] = cluster
+				members = append(members, zipCode)
+			default:
+				continue
+			}
+			neighbor, found := zc.Get(zipCode)
+			if !found {
+				continue
+			}
+			if expansion := neighborsOf(neighbor); len(expansion)+1 >= minPoints {
+				queue = append(queue, expansion...)
+			}
+		}
+		sort.Strings(members)
+		clusters = append(clusters, members)
+	}
+
+	noise = []string{}
+	for _, location := range locations {
+		if labels[zc.keyOf(location)] == noiseLabel {
+			noise = append(noise, zc.keyOf(location))
+		}
+	}
+	return clusters, noise, nil
+}
@@ -0,0 +1,51 @@
+package zipcodes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDBSCAN(t *testing.T) {
+	cases := []struct {
+		Dataset          string
+		EpsKm            float64
+		MinPoints        int
+		ExpectedClusters [][]string
+		ExpectedNoise    []string
+	}{
+		{"datasets/valid_dataset.txt", 100, 3, [][]string{{"19053", "20457", "22525"}}, []string{"01945", "03058", "34134", "87787", "94051"}},
+		{"datasets/valid_dataset.txt", 100, 2, [][]string{{"01945", "03058"}, {"19053", "20457", "22525"}}, []string{"34134", "87787", "94051"}},
+		{"datasets/valid_dataset.txt", 300, 1, [][]string{{"01945", "03058", "19053", "20457", "22525", "34134"}, {"87787", "94051"}}, []string{}},
+		{"datasets/valid_dataset.txt", 5, 2, [][]string{}, []string{"01945", "03058", "19053", "20457", "22525", "34134", "87787", "94051"}},
+		// C1 and C3 only have C2 as neighbor: they are not core zipcodes but
+		// join the cluster of C2, even if C1 was first labeled as noise
+		{"datasets/chain_dataset.txt", 110, 3, [][]string{{"C1", "C2", "C3"}}, []string{"C4"}},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		clusters, noise, err := zipcodesDataset.DBSCAN(c.EpsKm, c.MinPoints)
+		if err != nil {
+			t.Errorf("Unexpected error while clustering zipcodes %v", err)
+		}
+		if reflect.DeepEqual(clusters, c.ExpectedClusters) != true || reflect.DeepEqual(noise, c.ExpectedNoise) != true {
+			t.Errorf("DBSCAN returned unexpected clusters. Got %v/%v, want %v/%v", clusters, noise, c.ExpectedClusters, c.ExpectedNoise)
+		}
+	}
+
+	// Failing cases
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	_, _, err = zipcodesDataset.DBSCAN(0, 2)
+	if err == nil || err.Error() != "zipcodes: eps must be greater than zero" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: eps must be greater than zero")
+	}
+	_, _, err = zipcodesDataset.DBSCAN(10, 0)
+	if err == nil || err.Error() != "zipcodes: minPoints must be greater than zero" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: minPoints must be greater than zero")
+	}
+}
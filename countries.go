@@ -0,0 +1,95 @@
+package zipcodes
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// countryAliases maps the upper-cased names and ISO 3166-1 alpha-3 codes of
+// the major countries to the alpha-2 code used by the GeoNames datasets
+var countryAliases = map[string]string{
+	"USA": "US", "UNITED STATES": "US", "UNITED STATES OF AMERICA": "US",
+	"CAN": "CA", "CANADA": "CA",
+	"MEX": "MX", "MEXICO": "MX",
+	"BRA": "BR", "BRAZIL": "BR", "BRASIL": "BR",
+	"ARG": "AR", "ARGENTINA": "AR",
+	"GBR": "GB", "UK": "GB", "UNITED KINGDOM": "GB", "GREAT BRITAIN": "GB",
+	"IRL": "IE", "IRELAND": "IE",
+	"DEU": "DE", "GERMANY": "DE", "DEUTSCHLAND": "DE",
+	"AUT": "AT", "AUSTRIA": "AT", "ÖSTERREICH": "AT",
+	"CHE": "CH", "SWITZERLAND": "CH", "SCHWEIZ": "CH",
+	"FRA": "FR", "FRANCE": "FR",
+	"BEL": "BE", "BELGIUM": "BE",
+	"NLD": "NL", "NETHERLANDS": "NL", "HOLLAND": "NL",
+	"ESP": "ES", "SPAIN": "ES", "ESPAÑA": "ES",
+	"PRT": "PT", "PORTUGAL": "PT",
+	"ITA": "IT", "ITALY": "IT", "ITALIA": "IT",
+	"POL": "PL", "POLAND": "PL", "POLSKA": "PL",
+	"CZE": "CZ", "CZECHIA": "CZ", "CZECH REPUBLIC": "CZ",
+	"HRV": "HR", "CROATIA": "HR", "HRVATSKA": "HR",
+	"DNK": "DK", "DENMARK": "DK",
+	"SWE": "SE", "SWEDEN": "SE",
+	"NOR": "NO", "NORWAY": "NO",
+	"FIN": "FI", "FINLAND": "FI",
+	"RUS": "RU", "RUSSIA": "RU",
+	"TUR": "TR", "TURKEY": "TR",
+	"IND": "IN", "INDIA": "IN",
+	"CHN": "CN", "CHINA": "CN",
+	"JPN": "JP", "JAPAN": "JP",
+	"KOR": "KR", "SOUTH KOREA": "KR",
+	"VNM": "VN", "VIETNAM": "VN", "VIET NAM": "VN",
+	"AUS": "AU", "AUSTRALIA": "AU",
+	"NZL": "NZ", "NEW ZEALAND": "NZ",
+	"ZAF": "ZA", "SOUTH AFRICA": "ZA",
+}
+
+// countryAliasesMu guards countryAliases against concurrent registrations
+var countryAliasesMu sync.RWMutex
+
+// RegisterCountryAlias makes NormalizeCountryCode map the alias, whatever its
+// case, to the given ISO 3166-1 alpha-2 code. Registering an existing alias
+// replaces it.
+func RegisterCountryAlias(alias, iso2 string) {
+	countryAliasesMu.Lock()
+	defer countryAliasesMu.Unlock()
+	countryAliases[strings.ToUpper(strings.TrimSpace(alias))] = strings.ToUpper(strings.TrimSpace(iso2))
+}
+
+// NormalizeCountryCode returns the ISO 3166-1 alpha-2 code of a country given
+// as a code or a name in any case, e.g. "us", "USA" and "United States" all
+// return "US". Unknown values are returned upper-cased.
+func NormalizeCountryCode(country string) string {
+	key := strings.ToUpper(strings.TrimSpace(country))
+	countryAliasesMu.RLock()
+	defer countryAliasesMu.RUnlock()
+	if iso2, found := countryAliases[key]; found {
+		return iso2
+	}
+	return key
+}
+
+// LookupInCountry looks for a zipcode of a given country, which may be given
+// as a code or a name in any case (see NormalizeCountryCode). It is useful
+// when several countries were loaded and share zipcodes. With WithKeyFunc the
+// zipcode may be given as the key or as the bare zipcode, the latter scanning
+// the dataset.
+func (zc *Zipcodes) LookupInCountry(zipCode, country string) (*ZipCodeLocation, error) {
+	countryCode := NormalizeCountryCode(country)
+	rows := zc.records(zipCode)
+	if zc.keyFunc != nil {
+		// the rows are stored under their key, also match the bare zipcode
+		zc.Range(func(elm ZipCodeLocation) bool {
+			if elm.ZipCode == zipCode {
+				rows = append(rows, elm)
+			}
+			return true
+		})
+	}
+	for _, row := range rows {
+		if row.CountryCode == countryCode {
+			return &row, nil
+		}
+	}
+	return &ZipCodeLocation{}, fmt.Errorf("zipcodes: zipcode %s not found in country %s !", zipCode, countryCode)
+}
@@ -0,0 +1,87 @@
+package zipcodes
+
+import (
+	"testing"
+)
+
+func TestNormalizeCountryCode(t *testing.T) {
+	cases := []struct {
+		Country  string
+		Expected string
+	}{
+		{"US", "US"},
+		{"us", "US"},
+		{"USA", "US"},
+		{"United States", "US"},
+		{" germany ", "DE"},
+		{"España", "ES"},
+		{"fr", "FR"},
+		{"Atlantis", "ATLANTIS"},
+	}
+	for _, c := range cases {
+		if code := NormalizeCountryCode(c.Country); code != c.Expected {
+			t.Errorf("Unexpected country code for %s. Got %s, want %s", c.Country, code, c.Expected)
+		}
+	}
+
+	RegisterCountryAlias("Hrvatska Republika", "hr")
+	if code := NormalizeCountryCode("HRVATSKA REPUBLIKA"); code != "HR" {
+		t.Errorf("Unexpected country code for a registered alias. Got %s, want %s", code, "HR")
+	}
+}
+
+func TestLookupInCountry(t *testing.T) {
+	cases := []struct {
+		Country           string
+		ExpectedPlaceName string
+	}{
+		{"HR", "Zagreb"},
+		{"fr", "Troyes"},
+		{"France", "Troyes"},
+		{"VNM", "Hanoi"},
+	}
+	zipcodesDataset, err := New("datasets/duplicates_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		location, err := zipcodesDataset.LookupInCountry("10000", c.Country)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode in %s %v", c.Country, err)
+		}
+		if location.PlaceName != c.ExpectedPlaceName {
+			t.Errorf("Unexpected location for %s. Got %s, want %s", c.Country, location.PlaceName, c.ExpectedPlaceName)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.LookupInCountry("10000", "Germany")
+	if err == nil || err.Error() != "zipcodes: zipcode 10000 not found in country DE !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 10000 not found in country DE !")
+	}
+	_, err = zipcodesDataset.LookupInCountry("99999", "HR")
+	if err == nil || err.Error() != "zipcodes: zipcode 99999 not found in country HR !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 99999 not found in country HR !")
+	}
+
+	// Rows stored under a country and zipcode key
+	keyedDataset, err := New("datasets/duplicates_dataset.txt", WithKeyFunc(func(location ZipCodeLocation) string {
+		return location.CountryCode + "-" + location.ZipCode
+	}))
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, zipCode := range []string{"10000", "FR-10000"} {
+		location, err := keyedDataset.LookupInCountry(zipCode, "France")
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s in France %v", zipCode, err)
+		}
+		if location.PlaceName != "Troyes" {
+			t.Errorf("Unexpected location for %s. Got %s, want %s", zipCode, location.PlaceName, "Troyes")
+		}
+	}
+	_, err = keyedDataset.LookupInCountry("10000", "Germany")
+	if err == nil || err.Error() != "zipcodes: zipcode 10000 not found in country DE !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 10000 not found in country DE !")
+	}
+}
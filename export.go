@@ -0,0 +1,120 @@
+package zipcodes
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// sqliteDriverNames are the names the common SQLite drivers register
+// themselves with, github.com/mattn/go-sqlite3 and modernc.org/sqlite
+var sqliteDriverNames = []string{"sqlite3", "sqlite"}
+
+// ExportToSQLite writes the dataset into the zipcodes table of the SQLite
+// database at path, creating it if needed, for ad-hoc SQL analysis. The
+// package does not bundle a SQLite driver: the application has to import one,
+// e.g. github.com/mattn/go-sqlite3 or modernc.org/sqlite.
+func (zc *Zipcodes) ExportToSQLite(path string) error {
+	driverName := ""
+	registered := sql.Drivers()
+	for _, name := range sqliteDriverNames {
+		if i := sort.SearchStrings(registered, name); i < len(registered) && registered[i] == name {
+			driverName = name
+			break
+		}
+	}
+	if driverName == "" {
+		return fmt.Errorf("zipcodes: no SQLite driver registered, import one such as github.com/mattn/go-sqlite3")
+	}
+
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return fmt.Errorf("zipcodes: error while opening database %v", err)
+	}
+	if err := zc.ExportToSQL(db); err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
+
+// ExportToSQL writes the dataset into a zipcodes table (zipcode, place, admin,
+// state, country, lat, lon, accuracy) of the given database, with the zipcode
+// and state columns indexed. An existing zipcodes table is replaced. Rows are
+// inserted in a single transaction, sorted by zipcode, and missing coordinates
+// are stored as NULL. The statements use ? placeholders, as SQLite and MySQL do.
+func (zc *Zipcodes) ExportToSQL(db *sql.DB) error {
+	locations := zc.sortedLocations()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("zipcodes: error while exporting dataset %v", err)
+	}
+	if err := exportLocations(tx, locations); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("zipcodes: error while exporting dataset %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("zipcodes: error while exporting dataset %v", err)
+	}
+	return nil
+}
+
+// exportLocations creates the zipcodes table and inserts the locations in it
+func exportLocations(tx *sql.Tx, locations []ZipCodeLocation) error {
+	schema := []string{
+		"DROP TABLE IF EXISTS zipcodes",
+		"CREATE TABLE zipcodes (zipcode TEXT NOT NULL, place TEXT, admin TEXT, state TEXT, country TEXT, lat REAL, lon REAL, accuracy INTEGER)",
+		"CREATE INDEX zipcodes_zipcode ON zipcodes (zipcode)",
+		"CREATE INDEX zipcodes_state ON zipcodes (state)",
+	}
+	for _, statement := range schema {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	insert, err := tx.Prepare("INSERT INTO zipcodes (zipcode, place, admin, state, country, lat, lon, accuracy) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, elm := range locations {
+		var lat, lon interface{}
+		if elm.HasCoordinates() {
+			lat, lon = elm.Lat, elm.Lon
+		}
+		if _, err := insert.Exec(elm.ZipCode, elm.PlaceName, elm.AdminName, elm.StateCode, elm.CountryCode, lat, lon, elm.Accuracy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedLocations returns every location of the dataset sorted by zipcode
+func (zc *Zipcodes) sortedLocations() []ZipCodeLocation {
+	locations := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		locations = append(locations, elm)
+		return true
+	})
+	sort.Slice(locations, func(i, j int) bool {
+		return zc.keyOf(locations[i]) < zc.keyOf(locations[j])
+	})
+	return locations
+}
+
+// WriteNDJSON writes the dataset as newline delimited JSON, one ZipCodeSummary
+// object per line sorted by zipcode, for log and analytics pipelines that
+// ingest records one line at a time
+func (zc *Zipcodes) WriteNDJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, elm := range zc.sortedLocations() {
+		if err := encoder.Encode(elm.Summary()); err != nil {
+			return fmt.Errorf("zipcodes: error while writing NDJSON %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package zipcodes
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingDriver is a database/sql driver that records the statements it
+// receives instead of running them, registered as sqlite3 for the tests
+type recordingDriver struct {
+	mu         sync.Mutex
+	dsn        string
+	statements []recordedStatement
+	failOn     string
+}
+
+// recordedStatement is a statement executed through recordingDriver
+type recordedStatement struct {
+	Query string
+	Args  []driver.Value
+}
+
+var testSQLiteDriver = &recordingDriver{}
+
+func init() {
+	sql.Register("sqlite3", testSQLiteDriver)
+}
+
+func (d *recordingDriver) reset(failOn string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dsn, d.statements, d.failOn = "", nil, failOn
+}
+
+func (d *recordingDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.dsn = dsn
+	return recordingConn{d}, nil
+}
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{c.d, query}, nil
+}
+func (c recordingConn) Close() error              { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) { return recordingTx{}, nil }
+
+type recordingTx struct{}
+
+func (recordingTx) Commit() error   { return nil }
+func (recordingTx) Rollback() error { return nil }
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return -1 }
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.failOn != "" && strings.HasPrefix(s.query, s.d.failOn) {
+		return nil, fmt.Errorf("%s failed", s.d.failOn)
+	}
+	s.d.statements = append(s.d.statements, recordedStatement{Query: s.query, Args: args})
+	return driver.RowsAffected(1), nil
+}
+func (s recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("queries are not supported")
+}
+
+func TestExportToSQLite(t *testing.T) {
+	zipcodesDataset, err := New("datasets/blank_coordinates_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	testSQLiteDriver.reset("")
+	if err := zipcodesDataset.ExportToSQLite("zipcodes.db"); err != nil {
+		t.Errorf("Unexpected error while exporting dataset %v", err)
+	}
+	if testSQLiteDriver.dsn != "zipcodes.db" {
+		t.Errorf("Unexpected database path. Got %s, want %s", testSQLiteDriver.dsn, "zipcodes.db")
+	}
+
+	queries := []string{}
+	for _, statement := range testSQLiteDriver.statements {
+		queries = append(queries, strings.Fields(statement.Query)[0]+" "+strings.Fields(statement.Query)[1])
+	}
+	expectedQueries := []string{"DROP TABLE", "CREATE TABLE", "CREATE INDEX", "CREATE INDEX", "INSERT INTO", "INSERT INTO", "INSERT INTO"}
+	if reflect.DeepEqual(queries, expectedQueries) != true {
+		t.Errorf("Unexpected statements. Got %v, want %v", queries, expectedQueries)
+	}
+	if !strings.Contains(testSQLiteDriver.statements[2].Query, "(zipcode)") || !strings.Contains(testSQLiteDriver.statements[3].Query, "(state)") {
+		t.Errorf("Unexpected indexes. Got %s and %s", testSQLiteDriver.statements[2].Query, testSQLiteDriver.statements[3].Query)
+	}
+
+	expectedArgs := [][]driver.Value{
+		{"01945", "Guteborn", "Brandenburg", "BB", "DE", 51.4167, 13.9333, int64(4)},
+		{"03058", "Gablenz", "Brandenburg", "BB", "DE", 51.6865, 14.5094, int64(4)},
+		{"96799", "Pago Pago", "American Samoa", "AS", "AS", nil, nil, int64(0)},
+	}
+	for i, want := range expectedArgs {
+		if got := testSQLiteDriver.statements[4+i].Args; reflect.DeepEqual(got, want) != true {
+			t.Errorf("Unexpected inserted row. Got %v, want %v", got, want)
+		}
+	}
+
+	// Failing case
+	testSQLiteDriver.reset("CREATE INDEX")
+	err = zipcodesDataset.ExportToSQLite("zipcodes.db")
+	if err == nil || err.Error() != "zipcodes: error while exporting dataset CREATE INDEX failed" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: error while exporting dataset CREATE INDEX failed")
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, fmt.Errorf("disk full")
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	zipcodesDataset, err := New("datasets/blank_coordinates_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	var output strings.Builder
+	if err := zipcodesDataset.WriteNDJSON(&output); err != nil {
+		t.Errorf("Unexpected error while writing NDJSON %v", err)
+	}
+	expected := `{"zipCode":"01945","placeName":"Guteborn","stateCode":"BB","lat":51.4167,"lon":13.9333}
+{"zipCode":"03058","placeName":"Gablenz","stateCode":"BB","lat":51.6865,"lon":14.5094}
+{"zipCode":"96799","placeName":"Pago Pago","stateCode":"AS","lat":null,"lon":null}
+`
+	if output.String() != expected {
+		t.Errorf("Unexpected NDJSON. Got\n%s\nwant\n%s", output.String(), expected)
+	}
+
+	// Failing case
+	err = zipcodesDataset.WriteNDJSON(failingWriter{})
+	if err == nil || err.Error() != "zipcodes: error while writing NDJSON disk full" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: error while writing NDJSON disk full")
+	}
+}
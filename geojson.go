@@ -0,0 +1,85 @@
+package zipcodes
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// geoJSONFeatureCollection is the GeoJSON (RFC 7946) document returned by the GeoJSON methods
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// geoJSONFeature is a zipcode as a GeoJSON point feature
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	Geometry   geoJSONPoint      `json:"geometry"`
+	Properties geoJSONProperties `json:"properties"`
+}
+
+// geoJSONPoint holds the coordinates of a feature, longitude first as GeoJSON requires
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// geoJSONProperties are the zipcode fields exposed on each feature
+type geoJSONProperties struct {
+	ZipCode   string  `json:"zipCode"`
+	PlaceName string  `json:"placeName"`
+	AdminName string  `json:"adminName"`
+	StateCode string  `json:"stateCode"`
+	Distance  float64 `json:"distance"`
+}
+
+// ZipcodesWithinKmRadiusGeoJSON returns the zipcodes within the radius in
+// Kilometers of this zipcode as a GeoJSON FeatureCollection of points, ready
+// to be rendered on a map. Each feature has the zipcode fields and its
+// distance in Kilometers as properties, features are sorted by distance. On a
+// dataset loaded WithMaxRadiusResults, a truncated collection is returned
+// together with ErrTooManyResults.
+func (zc *Zipcodes) ZipcodesWithinKmRadiusGeoJSON(zipCode string, radius float64) ([]byte, error) {
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
+	if errLoc != nil {
+		return nil, errLoc
+	}
+
+	nearby := []ZipCodeWithDistance{}
+	var errMax error
+	center := newPoint(*location)
+	key := zc.keyOf(*location)
+	zc.rangeNear(center, radius, earthRadiusKm, func(p point) bool {
+		distance := center.distanceTo(p, earthRadiusKm)
+		if zc.keyOf(p.location) != key && distance < radius {
+			if zc.reachedMaxResults(len(nearby)) {
+				errMax = ErrTooManyResults
+				return false
+			}
+			nearby = append(nearby, ZipCodeWithDistance{ZipCodeLocation: p.location, Distance: distance})
+		}
+		return true
+	})
+	zc.sortByDistance(nearby)
+
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]geoJSONFeature, 0, len(nearby))}
+	for _, elm := range nearby {
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONPoint{Type: "Point", Coordinates: [2]float64{elm.Lon, elm.Lat}},
+			Properties: geoJSONProperties{
+				ZipCode:   elm.ZipCode,
+				PlaceName: elm.PlaceName,
+				AdminName: elm.AdminName,
+				StateCode: elm.StateCode,
+				Distance:  elm.Distance,
+			},
+		})
+	}
+
+	encoded, err := json.Marshal(collection)
+	if err != nil {
+		return nil, fmt.Errorf("zipcodes: error while encoding GeoJSON %v", err)
+	}
+	return encoded, errMax
+}
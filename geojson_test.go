@@ -0,0 +1,45 @@
+package zipcodes
+
+import (
+	"testing"
+)
+
+func TestZipcodesWithinKmRadiusGeoJSON(t *testing.T) {
+	cases := []struct {
+		ZipCode  string
+		Radius   float64
+		Expected string
+	}{
+		{
+			"20457",
+			100,
+			`{"type":"FeatureCollection","features":[` +
+				`{"type":"Feature","geometry":{"type":"Point","coordinates":[9.9161,53.605]},"properties":{"zipCode":"22525","placeName":"Hamburg Eidelstedt","adminName":"Hamburg","stateCode":"HH","distance":7.43}},` +
+				`{"type":"Feature","geometry":{"type":"Point","coordinates":[11.4092,53.6313]},"properties":{"zipCode":"19053","placeName":"Schwerin","adminName":"Mecklenburg-Vorpommern","stateCode":"MV","distance":94.8}}]}`,
+		},
+		{
+			"20457",
+			1,
+			`{"type":"FeatureCollection","features":[]}`,
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		encoded, err := zipcodesDataset.ZipcodesWithinKmRadiusGeoJSON(c.ZipCode, c.Radius)
+		if err != nil {
+			t.Errorf("Unexpected error while encoding GeoJSON %v", err)
+		}
+		if string(encoded) != c.Expected {
+			t.Errorf("ZipcodesWithinKmRadiusGeoJSON returned an unexpected document. Got %s, want %s", encoded, c.Expected)
+		}
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.ZipcodesWithinKmRadiusGeoJSON("XYZ", 10)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
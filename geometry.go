@@ -0,0 +1,376 @@
+package zipcodes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// radiansToDegrees converts radians to degrees
+func radiansToDegrees(r float64) float64 {
+	return r * 180 / math.Pi
+}
+
+// sphericalCentroid returns the weighted mean position of the given points
+// on the sphere by averaging their unit vectors, which avoids the skew of
+// a plain lat/lon average near the poles and the antimeridian.
+func sphericalCentroid(locations []ZipCodeLocation, weights []float64) (float64, float64, error) {
+	var x, y, z, total float64
+	for i, location := range locations {
+		lat := degreesToRadians(location.Lat)
+		lon := degreesToRadians(location.Lon)
+		x += weights[i] * math.Cos(lat) * math.Cos(lon)
+		y += weights[i] * math.Cos(lat) * math.Sin(lon)
+		z += weights[i] * math.Sin(lat)
+		total += weights[i]
+	}
+	if total <= 0 {
+		return 0, 0, fmt.Errorf("zipcodes: total weight must be greater than zero")
+	}
+	if math.Hypot(math.Hypot(x, y), z) < 1e-12 {
+		return 0, 0, fmt.Errorf("zipcodes: centroid is undefined for antipodal locations")
+	}
+
+	lat := math.Atan2(z, math.Hypot(x, y))
+	lon := math.Atan2(y, x)
+	return radiansToDegrees(lat), radiansToDegrees(lon), nil
+}
+
+// WeightedCentroid returns the center of mass of the given zipcodes, each one
+// contributing according to its weight. Only zipcodes present in the weights map
+// are taken into account, so a zipcode absent from it has weight 0.
+func (zc *Zipcodes) WeightedCentroid(weights map[string]float64) (lat, lon float64, err error) {
+	zipCodes := make([]string, 0, len(weights))
+	for zipCode := range weights {
+		zipCodes = append(zipCodes, zipCode)
+	}
+	sort.Strings(zipCodes)
+
+	locations := make([]ZipCodeLocation, 0, len(zipCodes))
+	values := make([]float64, 0, len(zipCodes))
+	for _, zipCode := range zipCodes {
+		if weights[zipCode] < 0 {
+			return 0, 0, fmt.Errorf("zipcodes: negative weight for zipcode %s", zipCode)
+		}
+		location, errLoc := zc.lookupWithCoordinates(zipCode)
+		if errLoc != nil {
+			return 0, 0, errLoc
+		}
+		locations = append(locations, *location)
+		values = append(values, weights[zipCode])
+	}
+
+	return sphericalCentroid(locations, values)
+}
+
+// PointCoveredBy reports whether the given lat/lon is within radiusKm of any
+// of the zipcodes, and which one. Zipcodes are checked in order and the search
+// stops at the first match, so zipcodes after it are not validated.
+func (zc *Zipcodes) PointCoveredBy(lat, lon float64, zipCodes []string, radiusKm float64) (bool, string, error) {
+	for _, zipCode := range zipCodes {
+		location, errLoc := zc.lookupWithCoordinates(zipCode)
+		if errLoc != nil {
+			return false, "", errLoc
+		}
+		if DistanceBetweenPoints(lat, lon, location.Lat, location.Lon, earthRadiusKm) < radiusKm {
+			return true, zc.keyOf(*location), nil
+		}
+	}
+	return false, "", nil
+}
+
+// initialBearing returns the initial bearing in radians to follow from the
+// first lat/lon to reach the second one along a great circle
+func initialBearing(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	lat1 := degreesToRadians(latitude1)
+	lat2 := degreesToRadians(latitude2)
+	diffLon := degreesToRadians(longitude2 - longitude1)
+
+	y := math.Sin(diffLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(diffLon)
+	return math.Atan2(y, x)
+}
+
+// ZipCodeWithBearing pairs a zipcode location with its bearing in degrees,
+// clockwise from north, and its distance in Kilometers from a reference zipcode
+type ZipCodeWithBearing struct {
+	ZipCodeLocation
+	Bearing  float64
+	Distance float64
+}
+
+// NeighborsByBearing returns the zipcodes within the radius in Kilometers of
+// the given zipcode sorted by the initial bearing to reach them, clockwise from
+// north, e.g. for compass-like layouts. Bearings are rounded to 2 decimals in
+// [0, 360) and zipcodes in the same direction are sorted by distance.
+func (zc *Zipcodes) NeighborsByBearing(zipCode string, radiusKm float64) ([]ZipCodeWithBearing, error) {
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
+	if errLoc != nil {
+		return nil, errLoc
+	}
+
+	neighbors := []ZipCodeWithBearing{}
+	center := newPoint(*location)
+	key := zc.keyOf(*location)
+	zc.rangeNear(center, radiusKm, earthRadiusKm, func(p point) bool {
+		if zc.keyOf(p.location) == key {
+			return true
+		}
+		distance := center.distanceTo(p, earthRadiusKm)
+		if distance < radiusKm {
+			bearing := radiansToDegrees(initialBearing(location.Lat, location.Lon, p.location.Lat, p.location.Lon))
+			bearing = math.Round(math.Mod(bearing+360, 360)*100) / 100
+			if bearing == 360 {
+				bearing = 0
+			}
+			neighbors = append(neighbors, ZipCodeWithBearing{ZipCodeLocation: p.location, Bearing: bearing, Distance: distance})
+		}
+		return true
+	})
+
+	sort.Slice(neighbors, func(i, j int) bool {
+		if neighbors[i].Bearing != neighbors[j].Bearing {
+			return neighbors[i].Bearing < neighbors[j].Bearing
+		}
+		return closer(neighbors[i].Distance, zc.keyOf(neighbors[i].ZipCodeLocation), neighbors[j].Distance, zc.keyOf(neighbors[j].ZipCodeLocation))
+	})
+	return neighbors, nil
+}
+
+// IsEdgeZipcode is a rough heuristic telling whether a zipcode lies near a
+// coast or a border: it reports true when, looking around from the zipcode,
+// the widest angle without any neighbor within radiusKm exceeds maxGapDegrees,
+// i.e. the neighbors are only on one side. A zipcode without neighbors is an
+// edge one. Sparse areas and irregular shapes easily fool it, so results are
+// meant for filtering, not as a ground truth.
+func (zc *Zipcodes) IsEdgeZipcode(zipCode string, radiusKm, maxGapDegrees float64) (bool, error) {
+	if maxGapDegrees <= 0 || maxGapDegrees > 360 {
+		return false, fmt.Errorf("zipcodes: maxGapDegrees must be greater than 0 and at most 360")
+	}
+	neighbors, err := zc.NeighborsByBearing(zipCode, radiusKm)
+	if err != nil {
+		return false, err
+	}
+	if len(neighbors) == 0 {
+		return true, nil
+	}
+
+	// neighbors are sorted by bearing, the gap between the last and the
+	// first one goes through north
+	widestGap := neighbors[0].Bearing + 360 - neighbors[len(neighbors)-1].Bearing
+	for i := 1; i < len(neighbors); i++ {
+		widestGap = math.Max(widestGap, neighbors[i].Bearing-neighbors[i-1].Bearing)
+	}
+	return widestGap > maxGapDegrees, nil
+}
+
+// intermediatePoint returns the lat/lon of the point at the given fraction of
+// the great circle segment going from a to b
+func intermediatePoint(a, b ZipCodeLocation, fraction float64) (float64, float64) {
+	lat1, lon1 := degreesToRadians(a.Lat), degreesToRadians(a.Lon)
+	lat2, lon2 := degreesToRadians(b.Lat), degreesToRadians(b.Lon)
+	angular := haversine(a.Lat, a.Lon, b.Lat, b.Lon, 1)
+	if angular == 0 {
+		return a.Lat, a.Lon
+	}
+
+	weightA := math.Sin((1-fraction)*angular) / math.Sin(angular)
+	weightB := math.Sin(fraction*angular) / math.Sin(angular)
+	x := weightA*math.Cos(lat1)*math.Cos(lon1) + weightB*math.Cos(lat2)*math.Cos(lon2)
+	y := weightA*math.Cos(lat1)*math.Sin(lon1) + weightB*math.Cos(lat2)*math.Sin(lon2)
+	z := weightA*math.Sin(lat1) + weightB*math.Sin(lat2)
+	return radiansToDegrees(math.Atan2(z, math.Sqrt(x*x+y*y))), radiansToDegrees(math.Atan2(y, x))
+}
+
+// distanceToSegment returns the unrounded distance from p to the closest point
+// of the great circle segment going from a to b
+func distanceToSegment(p, a, b ZipCodeLocation, radius float64) float64 {
+	distance, _ := projectOnSegment(p, a, b, radius)
+	return distance
+}
+
+// projectOnSegment returns the unrounded distance from p to the closest point
+// of the great circle segment going from a to b, and the distance from a to
+// that closest point along the segment
+func projectOnSegment(p, a, b ZipCodeLocation, radius float64) (distance, progress float64) {
+	distanceAP := haversine(a.Lat, a.Lon, p.Lat, p.Lon, radius)
+	distanceAB := haversine(a.Lat, a.Lon, b.Lat, b.Lon, radius)
+	if distanceAB == 0 {
+		return distanceAP, 0
+	}
+
+	angularAP := distanceAP / radius
+	diffBearing := initialBearing(a.Lat, a.Lon, p.Lat, p.Lon) - initialBearing(a.Lat, a.Lon, b.Lat, b.Lon)
+	if math.Cos(diffBearing) < 0 {
+		// p is behind a
+		return distanceAP, 0
+	}
+
+	crossTrack := math.Asin(math.Sin(angularAP) * math.Sin(diffBearing))
+	alongTrack := math.Acos(math.Max(-1, math.Min(1, math.Cos(angularAP)/math.Cos(crossTrack))))
+	if alongTrack*radius > distanceAB {
+		// p is beyond b
+		return haversine(b.Lat, b.Lon, p.Lat, p.Lon, radius), distanceAB
+	}
+	return math.Abs(crossTrack) * radius, alongTrack * radius
+}
+
+// DistanceToRoute returns the distance in Kilometers from a zipcode to the
+// closest point of a route, given as the ordered list of zipcodes it goes
+// through, each leg following the great circle between two consecutive zipcodes
+func (zc *Zipcodes) DistanceToRoute(zipCode string, route []string) (float64, error) {
+	if len(route) == 0 {
+		return 0, fmt.Errorf("zipcodes: route is empty")
+	}
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
+	if errLoc != nil {
+		return 0, errLoc
+	}
+	waypoints, err := zc.locations(route)
+	if err != nil {
+		return 0, err
+	}
+
+	nearest := haversine(location.Lat, location.Lon, waypoints[0].Lat, waypoints[0].Lon, earthRadiusKm)
+	for i := 1; i < len(waypoints); i++ {
+		nearest = math.Min(nearest, distanceToSegment(*location, waypoints[i-1], waypoints[i], earthRadiusKm))
+	}
+	return math.Round(nearest*100) / 100, nil
+}
+
+// RouteCoverageFraction samples a route, given as the ordered list of zipcodes
+// it goes through, every sampleKm along its great circle legs and returns the
+// fraction of samples with at least one zipcode within corridorKm. The last
+// waypoint is always sampled. A low fraction points to remote stretches.
+func (zc *Zipcodes) RouteCoverageFraction(waypoints []string, corridorKm, sampleKm float64) (float64, error) {
+	if len(waypoints) == 0 {
+		return 0, fmt.Errorf("zipcodes: route is empty")
+	}
+	if corridorKm <= 0 || sampleKm <= 0 {
+		return 0, fmt.Errorf("zipcodes: corridor and sample distances must be greater than zero")
+	}
+	route, err := zc.locations(waypoints)
+	if err != nil {
+		return 0, err
+	}
+
+	covered, samples := 0, 0
+	sample := func(lat, lon float64) {
+		samples++
+		center := newPoint(ZipCodeLocation{Lat: lat, Lon: lon})
+		zc.rangeNear(center, corridorKm, earthRadiusKm, func(p point) bool {
+			if center.distanceTo(p, earthRadiusKm) < corridorKm {
+				covered++
+				return false
+			}
+			return true
+		})
+	}
+
+	// offset is the distance from the start of the current leg to the next sample
+	offset := 0.0
+	for i := 1; i < len(route); i++ {
+		legKm := haversine(route[i-1].Lat, route[i-1].Lon, route[i].Lat, route[i].Lon, earthRadiusKm)
+		for ; offset < legKm; offset += sampleKm {
+			sample(intermediatePoint(route[i-1], route[i], offset/legKm))
+		}
+		offset -= legKm
+	}
+	last := route[len(route)-1]
+	sample(last.Lat, last.Lon)
+
+	return float64(covered) / float64(samples), nil
+}
+
+// ZipcodesBetween returns the zipcodes within corridorKm of the great circle
+// segment going from a to b, a and b themselves excluded, e.g. to find stops
+// on the way. Each one comes with its distance in Kilometers to the segment
+// and the list is sorted by progress along the way from a to b.
+func (zc *Zipcodes) ZipcodesBetween(a, b string, corridorKm float64) ([]ZipCodeWithDistance, error) {
+	locationA, errLocA := zc.lookupWithCoordinates(a)
+	if errLocA != nil {
+		return nil, errLocA
+	}
+	locationB, errLocB := zc.lookupWithCoordinates(b)
+	if errLocB != nil {
+		return nil, errLocB
+	}
+
+	between := []ZipCodeWithDistance{}
+	progress := make(map[string]float64)
+	keyA, keyB := zc.keyOf(*locationA), zc.keyOf(*locationB)
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if !elm.HasCoordinates() || zc.keyOf(elm) == keyA || zc.keyOf(elm) == keyB {
+			return true
+		}
+		distance, along := projectOnSegment(elm, *locationA, *locationB, earthRadiusKm)
+		distance = math.Round(distance*100) / 100
+		if distance < corridorKm {
+			between = append(between, ZipCodeWithDistance{ZipCodeLocation: elm, Distance: distance})
+			progress[zc.keyOf(elm)] = along
+		}
+		return true
+	})
+
+	sort.Slice(between, func(i, j int) bool {
+		keyI, keyJ := zc.keyOf(between[i].ZipCodeLocation), zc.keyOf(between[j].ZipCodeLocation)
+		return closer(progress[keyI], keyI, progress[keyJ], keyJ)
+	})
+	return between, nil
+}
+
+// Extremes returns the northernmost, southernmost, easternmost and westernmost
+// zipcodes of the dataset, computed in a single pass. East and west are plain
+// longitude extremes, the antimeridian is not taken into account. Ties go to
+// the lowest zipcode and zero values are returned when no zipcode has coordinates.
+func (zc *Zipcodes) Extremes() (north, south, east, west ZipCodeLocation) {
+	first := true
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if !elm.HasCoordinates() {
+			return true
+		}
+		if first {
+			north, south, east, west = elm, elm, elm, elm
+			first = false
+			return true
+		}
+		if closer(-elm.Lat, zc.keyOf(elm), -north.Lat, zc.keyOf(north)) {
+			north = elm
+		}
+		if closer(elm.Lat, zc.keyOf(elm), south.Lat, zc.keyOf(south)) {
+			south = elm
+		}
+		if closer(-elm.Lon, zc.keyOf(elm), -east.Lon, zc.keyOf(east)) {
+			east = elm
+		}
+		if closer(elm.Lon, zc.keyOf(elm), west.Lon, zc.keyOf(west)) {
+			west = elm
+		}
+		return true
+	})
+	return north, south, east, west
+}
+
+// ZipcodesInTimezoneBand returns the zipcodes whose longitude falls within
+// 7.5 degrees of utcOffset × 15, sorted by zipcode, e.g. -5 for the zipcodes
+// between -82.5 and -67.5. It is a rough approximation for scheduling by local
+// time: actual time zone boundaries and daylight saving time are ignored. The
+// band wraps around the antimeridian, so +12 and -12 return the same zipcodes.
+func (zc *Zipcodes) ZipcodesInTimezoneBand(utcOffset float64) []ZipCodeLocation {
+	center := utcOffset * 15
+	zipcodeList := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if !elm.HasCoordinates() {
+			return true
+		}
+		if diff := normalizeLonDelta(elm.Lon - center); diff >= -7.5 && diff < 7.5 {
+			zipcodeList = append(zipcodeList, elm)
+		}
+		return true
+	})
+
+	sort.Slice(zipcodeList, func(i, j int) bool {
+		return zc.keyOf(zipcodeList[i]) < zc.keyOf(zipcodeList[j])
+	})
+	return zipcodeList
+}
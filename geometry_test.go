@@ -0,0 +1,420 @@
+package zipcodes
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestWeightedCentroid(t *testing.T) {
+	cases := []struct {
+		Weights     map[string]float64
+		ExpectedLat float64
+		ExpectedLon float64
+	}{
+		{
+			map[string]float64{"01945": 1},
+			51.4167,
+			13.9333,
+		},
+		{
+			map[string]float64{"01945": 3, "03058": 0},
+			51.4167,
+			13.9333,
+		},
+		{
+			map[string]float64{"20457": 1, "22525": 1},
+			53.5774,
+			9.9478,
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		lat, lon, err := zipcodesDataset.WeightedCentroid(c.Weights)
+		if err != nil {
+			t.Errorf("Unexpected error while computing centroid %v", err)
+		}
+		if math.Abs(lat-c.ExpectedLat) > 1e-4 || math.Abs(lon-c.ExpectedLon) > 1e-4 {
+			t.Errorf("Centroid does not match. Expected %v/%v, got %v/%v", c.ExpectedLat, c.ExpectedLon, lat, lon)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		Weights     map[string]float64
+		ExpectedErr string
+	}{
+		{
+			map[string]float64{"01945": 1, "XYZ": 1},
+			"zipcodes: zipcode XYZ not found !",
+		},
+		{
+			map[string]float64{"01945": 0},
+			"zipcodes: total weight must be greater than zero",
+		},
+		{
+			map[string]float64{"01945": -1},
+			"zipcodes: negative weight for zipcode 01945",
+		},
+	}
+	for _, c := range fail {
+		_, _, err := zipcodesDataset.WeightedCentroid(c.Weights)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestPointCoveredBy(t *testing.T) {
+	cases := []struct {
+		Lat             float64
+		Lon             float64
+		ZipCodes        []string
+		RadiusKm        float64
+		ExpectedCovered bool
+		ExpectedZipCode string
+	}{
+		{51.4267, 13.9333, []string{"20457", "01945", "03058"}, 5, true, "01945"},
+		{51.4267, 13.9333, []string{"20457", "03058"}, 5, false, ""},
+		{51.4267, 13.9333, []string{"03058", "01945"}, 100, true, "03058"},
+		{51.4267, 13.9333, []string{}, 100, false, ""},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		covered, zipCode, err := zipcodesDataset.PointCoveredBy(c.Lat, c.Lon, c.ZipCodes, c.RadiusKm)
+		if err != nil {
+			t.Errorf("Unexpected error while checking coverage %v", err)
+		}
+		if covered != c.ExpectedCovered || zipCode != c.ExpectedZipCode {
+			t.Errorf("Unexpected coverage. Got %v/%s, want %v/%s", covered, zipCode, c.ExpectedCovered, c.ExpectedZipCode)
+		}
+	}
+
+	_, _, err = zipcodesDataset.PointCoveredBy(51.4267, 13.9333, []string{"11111", "01945"}, 5)
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+}
+
+func TestDistanceToRoute(t *testing.T) {
+	cases := []struct {
+		ZipCode    string
+		Route      []string
+		ExpectedKM float64
+	}{
+		{"P1", []string{"R1", "R2"}, 111.19},
+		{"P2", []string{"R1", "R2"}, 157.25},
+		{"P3", []string{"R1", "R2"}, 111.19},
+		{"R1", []string{"R1", "R2"}, 0},
+		{"P1", []string{"R2"}, 157.25},
+		{"P2", []string{"R1", "R2", "R3"}, 111.18},
+	}
+	zipcodesDataset, err := New("datasets/route_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		kms, err := zipcodesDataset.DistanceToRoute(c.ZipCode, c.Route)
+		if err != nil {
+			t.Errorf("Unexpected error while computing distance to route %v", err)
+		}
+		if kms != c.ExpectedKM {
+			t.Errorf("Distance from %s to %v does not match. Expected %v, got %v", c.ZipCode, c.Route, c.ExpectedKM, kms)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		ZipCode     string
+		Route       []string
+		ExpectedErr string
+	}{
+		{"P1", []string{}, "zipcodes: route is empty"},
+		{"XYZ", []string{"R1", "R2"}, "zipcodes: zipcode XYZ not found !"},
+		{"P1", []string{"R1", "11111"}, "zipcodes: zipcode 11111 not found !"},
+	}
+	for _, c := range fail {
+		_, err := zipcodesDataset.DistanceToRoute(c.ZipCode, c.Route)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestNeighborsByBearing(t *testing.T) {
+	zipcodesDataset, err := New("datasets/route_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	cases := []struct {
+		RadiusKm float64
+		Expected []ZipCodeWithBearing
+	}{
+		{400, []ZipCodeWithBearing{
+			{ZipCodeLocation: zipcodesDataset.DatasetList["R3"], Bearing: 0, Distance: 222.39},
+			{ZipCodeLocation: zipcodesDataset.DatasetList["P2"], Bearing: 45, Distance: 157.25},
+			{ZipCodeLocation: zipcodesDataset.DatasetList["R1"], Bearing: 270, Distance: 222.39},
+			{ZipCodeLocation: zipcodesDataset.DatasetList["P3"], Bearing: 270, Distance: 333.58},
+			{ZipCodeLocation: zipcodesDataset.DatasetList["P1"], Bearing: 315, Distance: 157.25},
+		}},
+		{200, []ZipCodeWithBearing{
+			{ZipCodeLocation: zipcodesDataset.DatasetList["P2"], Bearing: 45, Distance: 157.25},
+			{ZipCodeLocation: zipcodesDataset.DatasetList["P1"], Bearing: 315, Distance: 157.25},
+		}},
+		{100, []ZipCodeWithBearing{}},
+	}
+	for _, c := range cases {
+		neighbors, err := zipcodesDataset.NeighborsByBearing("R2", c.RadiusKm)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for neighbors %v", err)
+		}
+		if reflect.DeepEqual(neighbors, c.Expected) != true {
+			t.Errorf("NeighborsByBearing returned an unexpected list within %v. Got %v, want %v", c.RadiusKm, neighbors, c.Expected)
+		}
+	}
+
+	// Bearings are rounded once normalized
+	expected := []ZipCodeWithBearing{
+		{ZipCodeLocation: zipcodesDataset.DatasetList["R3"], Bearing: 44.98, Distance: 157.23},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["R2"], Bearing: 135, Distance: 157.25},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["R1"], Bearing: 225, Distance: 157.25},
+	}
+	if neighbors, _ := zipcodesDataset.NeighborsByBearing("P1", 200); reflect.DeepEqual(neighbors, expected) != true {
+		t.Errorf("NeighborsByBearing returned an unexpected list. Got %v, want %v", neighbors, expected)
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.NeighborsByBearing("XYZ", 100)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestIsEdgeZipcode(t *testing.T) {
+	cases := []struct {
+		Dataset       string
+		ZipCode       string
+		RadiusKm      float64
+		MaxGapDegrees float64
+		Expected      bool
+	}{
+		// neighbors on the east and the west leave two 180 degrees gaps
+		{"datasets/chain_dataset.txt", "C2", 150, 180, false},
+		{"datasets/chain_dataset.txt", "C2", 150, 170, true},
+		// a single neighbor leaves a full turn
+		{"datasets/chain_dataset.txt", "C1", 150, 360, false},
+		{"datasets/chain_dataset.txt", "C1", 150, 300, true},
+		// no neighbor at all
+		{"datasets/chain_dataset.txt", "C4", 150, 360, true},
+		// neighbors at 44.98, 135 and 225 degrees leave 179.98 degrees on the north west
+		{"datasets/route_dataset.txt", "P1", 200, 180, false},
+		{"datasets/route_dataset.txt", "P1", 200, 179.9, true},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		edge, err := zipcodesDataset.IsEdgeZipcode(c.ZipCode, c.RadiusKm, c.MaxGapDegrees)
+		if err != nil {
+			t.Errorf("Unexpected error while checking %s %v", c.ZipCode, err)
+		}
+		if edge != c.Expected {
+			t.Errorf("Unexpected result for %s with a %v degrees gap. Got %v, want %v", c.ZipCode, c.MaxGapDegrees, edge, c.Expected)
+		}
+	}
+
+	// Failing cases
+	zipcodesDataset, err := New("datasets/chain_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, gap := range []float64{0, 361} {
+		_, err = zipcodesDataset.IsEdgeZipcode("C1", 150, gap)
+		if err == nil || err.Error() != "zipcodes: maxGapDegrees must be greater than 0 and at most 360" {
+			t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: maxGapDegrees must be greater than 0 and at most 360")
+		}
+	}
+	_, err = zipcodesDataset.IsEdgeZipcode("XYZ", 150, 180)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestIntermediatePoint(t *testing.T) {
+	cases := []struct {
+		A, B        ZipCodeLocation
+		Fraction    float64
+		ExpectedLat float64
+		ExpectedLon float64
+	}{
+		{ZipCodeLocation{Lat: 0, Lon: 0}, ZipCodeLocation{Lat: 0, Lon: 2}, 0.5, 0, 1},
+		{ZipCodeLocation{Lat: 0, Lon: 2}, ZipCodeLocation{Lat: 2, Lon: 2}, 0.25, 0.5, 2},
+		{ZipCodeLocation{Lat: 10, Lon: 10}, ZipCodeLocation{Lat: 10, Lon: 10}, 0.5, 10, 10},
+		{ZipCodeLocation{Lat: 0, Lon: 0}, ZipCodeLocation{Lat: 0, Lon: 2}, 0, 0, 0},
+	}
+	for _, c := range cases {
+		lat, lon := intermediatePoint(c.A, c.B, c.Fraction)
+		if math.Abs(lat-c.ExpectedLat) > 1e-9 || math.Abs(lon-c.ExpectedLon) > 1e-9 {
+			t.Errorf("Unexpected intermediate point at %v. Got %v/%v, want %v/%v", c.Fraction, lat, lon, c.ExpectedLat, c.ExpectedLon)
+		}
+	}
+}
+
+func TestRouteCoverageFraction(t *testing.T) {
+	cases := []struct {
+		Route            []string
+		CorridorKm       float64
+		SampleKm         float64
+		ExpectedFraction float64
+	}{
+		{[]string{"R1", "R2", "R3"}, 60, 50, 0.6},
+		{[]string{"R1", "R2", "R3"}, 30, 20, 1.0 / 3},
+		{[]string{"R1", "R2", "R3"}, 120, 50, 1},
+		{[]string{"R1", "R2", "R3"}, 60, 500, 1},
+		{[]string{"R1"}, 1, 1, 1},
+	}
+	zipcodesDataset, err := New("datasets/route_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		fraction, err := zipcodesDataset.RouteCoverageFraction(c.Route, c.CorridorKm, c.SampleKm)
+		if err != nil {
+			t.Errorf("Unexpected error while computing route coverage %v", err)
+		}
+		if fraction != c.ExpectedFraction {
+			t.Errorf("Coverage of %v does not match. Expected %v, got %v", c.Route, c.ExpectedFraction, fraction)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		Route       []string
+		CorridorKm  float64
+		SampleKm    float64
+		ExpectedErr string
+	}{
+		{[]string{}, 60, 50, "zipcodes: route is empty"},
+		{[]string{"R1", "R2"}, 0, 50, "zipcodes: corridor and sample distances must be greater than zero"},
+		{[]string{"R1", "R2"}, 60, 0, "zipcodes: corridor and sample distances must be greater than zero"},
+		{[]string{"R1", "11111"}, 60, 50, "zipcodes: zipcode 11111 not found !"},
+	}
+	for _, c := range fail {
+		_, err := zipcodesDataset.RouteCoverageFraction(c.Route, c.CorridorKm, c.SampleKm)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestZipcodesBetween(t *testing.T) {
+	cases := []struct {
+		Dataset      string
+		A            string
+		B            string
+		CorridorKm   float64
+		ExpectedList []string
+		ExpectedKms  []float64
+	}{
+		{"datasets/route_dataset.txt", "P3", "R2", 120, []string{"R1", "P1"}, []float64{0, 111.19}},
+		{"datasets/route_dataset.txt", "P3", "R2", 100, []string{"R1"}, []float64{0}},
+		{"datasets/valid_dataset.txt", "20457", "94051", 100, []string{"22525", "19053"}, []float64{7.43, 88.84}},
+		{"datasets/valid_dataset.txt", "20457", "94051", 5, []string{}, []float64{}},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		between, err := zipcodesDataset.ZipcodesBetween(c.A, c.B, c.CorridorKm)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcodes between %s and %s %v", c.A, c.B, err)
+		}
+		list := []string{}
+		kms := []float64{}
+		for _, elm := range between {
+			list = append(list, elm.ZipCode)
+			kms = append(kms, elm.Distance)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true || reflect.DeepEqual(kms, c.ExpectedKms) != true {
+			t.Errorf("ZipcodesBetween returned an unexpected list. Got %v/%v, want %v/%v", list, kms, c.ExpectedList, c.ExpectedKms)
+		}
+	}
+
+	// Failing cases
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	_, err = zipcodesDataset.ZipcodesBetween("11111", "01945", 10)
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+	_, err = zipcodesDataset.ZipcodesBetween("01945", "22222", 10)
+	if err == nil || err.Error() != "zipcodes: zipcode 22222 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 22222 not found !")
+	}
+}
+
+func TestExtremes(t *testing.T) {
+	cases := []struct {
+		Dataset  string
+		Expected [4]string
+	}{
+		{"datasets/valid_dataset.txt", [4]string{"19053", "87787", "03058", "34134"}},
+		{"datasets/tie_dataset.txt", [4]string{"T0", "T0", "T1", "T2"}},
+		{"datasets/blank_coordinates_dataset.txt", [4]string{"03058", "01945", "03058", "01945"}},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset, WithBlankCoordinates())
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		north, south, east, west := zipcodesDataset.Extremes()
+		got := [4]string{north.ZipCode, south.ZipCode, east.ZipCode, west.ZipCode}
+		if got != c.Expected {
+			t.Errorf("Unexpected extremes for %s. Got %v, want %v", c.Dataset, got, c.Expected)
+		}
+	}
+
+	north, south, east, west := NewFromSource(sliceSource{}).Extremes()
+	if north != (ZipCodeLocation{}) || south != (ZipCodeLocation{}) || east != (ZipCodeLocation{}) || west != (ZipCodeLocation{}) {
+		t.Errorf("Unexpected extremes for an empty dataset. Got %v %v %v %v", north, south, east, west)
+	}
+}
+
+func TestZipcodesInTimezoneBand(t *testing.T) {
+	cases := []struct {
+		Dataset      string
+		UTCOffset    float64
+		ExpectedList []string
+	}{
+		{"datasets/valid_dataset.txt", 1, []string{"01945", "03058", "19053", "20457", "22525", "34134", "87787", "94051"}},
+		{"datasets/valid_dataset.txt", 0, []string{}},
+		{"datasets/valid_dataset.txt", -5, []string{}},
+		{"datasets/antimeridian_dataset.txt", 12, []string{"FJ01", "FJ02", "WS01"}},
+		{"datasets/antimeridian_dataset.txt", -12, []string{"FJ01", "FJ02", "WS01"}},
+		{"datasets/antimeridian_dataset.txt", -10, []string{"US01"}},
+		{"datasets/antimeridian_dataset.txt", 0.5, []string{"NO01"}},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		list := []string{}
+		for _, elm := range zipcodesDataset.ZipcodesInTimezoneBand(c.UTCOffset) {
+			list = append(list, elm.ZipCode)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("ZipcodesInTimezoneBand returned an unexpected list for UTC%+v. Got %v, want %v", c.UTCOffset, list, c.ExpectedList)
+		}
+	}
+}
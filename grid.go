@@ -0,0 +1,71 @@
+package zipcodes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// gridCellIndex returns the row and column of the sizeDeg × sizeDeg cell a
+// lat/lon falls in. Cell (0, 0) starts at lat 0, lon 0.
+func gridCellIndex(lat, lon, sizeDeg float64) (int, int) {
+	return int(math.Floor(lat / sizeDeg)), int(math.Floor(lon / sizeDeg))
+}
+
+// GridCellOf returns the row and column of the sizeDeg × sizeDeg grid cell the
+// zipcode falls in, i.e. floor(lat / sizeDeg) and floor(lon / sizeDeg)
+func (zc *Zipcodes) GridCellOf(zipCode string, sizeDeg float64) (int, int, error) {
+	if sizeDeg <= 0 {
+		return 0, 0, fmt.Errorf("zipcodes: cell size must be greater than zero")
+	}
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
+	if errLoc != nil {
+		return 0, 0, errLoc
+	}
+	row, col := gridCellIndex(location.Lat, location.Lon, sizeDeg)
+	return row, col, nil
+}
+
+// ZipcodesInGridCell returns the zipcodes of the sizeDeg × sizeDeg grid cell
+// containing the given lat/lon (e.g. the south-west corner of the cell),
+// sorted by zipcode. It returns an empty list when sizeDeg is not positive.
+func (zc *Zipcodes) ZipcodesInGridCell(cellLat, cellLon, sizeDeg float64) []ZipCodeLocation {
+	locations := []ZipCodeLocation{}
+	if sizeDeg <= 0 {
+		return locations
+	}
+	row, col := gridCellIndex(cellLat, cellLon, sizeDeg)
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if !elm.HasCoordinates() {
+			return true
+		}
+		if elmRow, elmCol := gridCellIndex(elm.Lat, elm.Lon, sizeDeg); elmRow == row && elmCol == col {
+			locations = append(locations, elm)
+		}
+		return true
+	})
+
+	sort.Slice(locations, func(i, j int) bool {
+		return zc.keyOf(locations[i]) < zc.keyOf(locations[j])
+	})
+	return locations
+}
+
+// DensityGrid returns the number of zipcodes in every sizeDeg × sizeDeg grid
+// cell holding at least one, keyed by the row and column GridCellOf returns,
+// e.g. to render a density heatmap without population data. Zipcodes without
+// coordinates are left out. It returns an empty map when sizeDeg is not positive.
+func (zc *Zipcodes) DensityGrid(sizeDeg float64) map[[2]int]int {
+	density := make(map[[2]int]int)
+	if sizeDeg <= 0 {
+		return density
+	}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			row, col := gridCellIndex(elm.Lat, elm.Lon, sizeDeg)
+			density[[2]int{row, col}]++
+		}
+		return true
+	})
+	return density
+}
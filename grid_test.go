@@ -0,0 +1,96 @@
+package zipcodes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGridCellOf(t *testing.T) {
+	cases := []struct {
+		ZipCode     string
+		SizeDeg     float64
+		ExpectedRow int
+		ExpectedCol int
+	}{
+		{"01945", 0.5, 102, 27},
+		{"01945", 1, 51, 13},
+		{"20457", 10, 5, 0},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		row, col, err := zipcodesDataset.GridCellOf(c.ZipCode, c.SizeDeg)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for grid cell %v", err)
+		}
+		if row != c.ExpectedRow || col != c.ExpectedCol {
+			t.Errorf("Unexpected grid cell. Got %d/%d, want %d/%d", row, col, c.ExpectedRow, c.ExpectedCol)
+		}
+	}
+
+	// Failing cases
+	_, _, err = zipcodesDataset.GridCellOf("01945", 0)
+	if err == nil || err.Error() != "zipcodes: cell size must be greater than zero" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: cell size must be greater than zero")
+	}
+	_, _, err = zipcodesDataset.GridCellOf("XYZ", 1)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestZipcodesInGridCell(t *testing.T) {
+	cases := []struct {
+		CellLat      float64
+		CellLon      float64
+		SizeDeg      float64
+		ExpectedList []string
+	}{
+		{51, 13, 1, []string{"01945"}},
+		{51, 14, 1, []string{"03058"}},
+		{51, 13, 4, []string{"01945", "03058", "94051"}},
+		{53.5, 9.5, 0.5, []string{"20457", "22525"}},
+		{0, 0, 1, []string{}},
+		{51, 13, 0, []string{}},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		list := []string{}
+		for _, elm := range zipcodesDataset.ZipcodesInGridCell(c.CellLat, c.CellLon, c.SizeDeg) {
+			list = append(list, elm.ZipCode)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("Unexpected zipcode list returned. Got %v, want %v", list, c.ExpectedList)
+		}
+	}
+}
+
+func TestDensityGrid(t *testing.T) {
+	cases := []struct {
+		Dataset  string
+		Opts     []Option
+		SizeDeg  float64
+		Expected map[[2]int]int
+	}{
+		{"datasets/valid_dataset.txt", nil, 4, map[[2]int]int{{12, 3}: 3, {11, 2}: 1, {12, 2}: 1, {13, 2}: 3}},
+		{"datasets/valid_dataset.txt", nil, 90, map[[2]int]int{{0, 0}: 8}},
+		{"datasets/blank_coordinates_dataset.txt", []Option{WithBlankCoordinates()}, 1, map[[2]int]int{{51, 13}: 1, {51, 14}: 1}},
+		{"datasets/valid_dataset.txt", nil, 0, map[[2]int]int{}},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset, c.Opts...)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+			continue
+		}
+		density := zipcodesDataset.DensityGrid(c.SizeDeg)
+		if reflect.DeepEqual(density, c.Expected) != true {
+			t.Errorf("Unexpected density grid of %s with %v degree cells. Got %v, want %v", c.Dataset, c.SizeDeg, density, c.Expected)
+		}
+	}
+}
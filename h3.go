@@ -0,0 +1,895 @@
+package zipcodes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// The H3 indexing below is a port of latLngToCell from Uber's H3 library
+// (https://h3geo.org, Apache License 2.0): a point is projected on the closest
+// face of an icosahedron, located on that face's hexagonal grid at the
+// requested resolution, and walked up the aperture 7 hierarchy to a base cell,
+// recording one digit per resolution.
+
+const (
+	// h3MaxResolution is the finest H3 resolution
+	h3MaxResolution = 15
+	// h3Res0UGnomonic scales gnomonic distances to resolution 0 hex units
+	h3Res0UGnomonic = 0.38196601125010500003
+	// h3Ap7RotRads rotates Class II grids into the Class III (odd) resolutions
+	h3Ap7RotRads = 0.333473172251832115336090755351601070065900389
+	h3Epsilon    = 0.0000000000000001
+	// h3InitIndex is mode 1 (cell) with every digit set to 7 (unused)
+	h3InitIndex = uint64(1)<<59 | 0x1fffffffffff
+)
+
+// h3CoordIJK is a position on a face's hexagonal grid along three axes 120°
+// apart; normalized coordinates are non negative with at least one zero.
+type h3CoordIJK struct {
+	i, j, k int
+}
+
+// h3BaseCell is one of the 122 resolution 0 cells: its home face and
+// position there. Pentagons also list the two faces whose rotations are
+// offset clockwise.
+type h3BaseCell struct {
+	face     int
+	coord    h3CoordIJK
+	pentagon bool
+	cwOffset [2]int
+}
+
+// h3FaceBaseCell is the base cell at a resolution 0 position of a face and
+// the number of 60° ccw rotations from that face to the base cell's frame.
+type h3FaceBaseCell struct {
+	baseCell int
+	ccwRot60 int
+}
+
+// H3CellOf returns the index, as the usual hexadecimal string, of the H3 cell
+// at the given resolution (0 to 15) containing the zipcode's centroid.
+func (zc *Zipcodes) H3CellOf(zipCode string, resolution int) (string, error) {
+	if resolution < 0 || resolution > h3MaxResolution {
+		return "", fmt.Errorf("zipcodes: H3 resolution must be between 0 and %d", h3MaxResolution)
+	}
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
+	if errLoc != nil {
+		return "", errLoc
+	}
+	return h3CellString(location.Lat, location.Lon, resolution), nil
+}
+
+// ZipcodesByH3Cell groups the zipcodes by the H3 cell at the given resolution
+// containing their centroid, keyed by cell index and sorted by zipcode.
+// Zipcodes without coordinates are left out. It returns an empty map when the
+// resolution is not between 0 and 15.
+func (zc *Zipcodes) ZipcodesByH3Cell(resolution int) map[string][]string {
+	cells := make(map[string][]string)
+	if resolution < 0 || resolution > h3MaxResolution {
+		return cells
+	}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			cell := h3CellString(elm.Lat, elm.Lon, resolution)
+			cells[cell] = append(cells[cell], zc.keyOf(elm))
+		}
+		return true
+	})
+
+	for _, zipCodes := range cells {
+		sort.Strings(zipCodes)
+	}
+	return cells
+}
+
+// h3CellString returns the hexadecimal index of the cell containing lat/lon
+func h3CellString(lat, lon float64, res int) string {
+	return strconv.FormatUint(h3Cell(lat, lon, res), 16)
+}
+
+// h3Cell returns the index of the cell at resolution res containing lat/lon
+func h3Cell(lat, lon float64, res int) uint64 {
+	face, x, y := h3GeoToHex2d(degreesToRadians(lat), degreesToRadians(lon), res)
+	return h3FaceIJKToCell(face, h3Hex2dToCoordIJK(x, y), res)
+}
+
+// h3GeoToHex2d projects a point, in radians, on the closest icosahedron face
+// and returns its 2D position on that face's grid at resolution res.
+func h3GeoToHex2d(lat, lon float64, res int) (int, float64, float64) {
+	point := h3PointOf(lat, lon)
+	face, sqd := 0, math.Inf(1)
+	for f, center := range h3FaceCenterPoints {
+		dx, dy, dz := center[0]-point[0], center[1]-point[1], center[2]-point[2]
+		if d := dx*dx + dy*dy + dz*dz; d < sqd {
+			face, sqd = f, d
+		}
+	}
+
+	r := math.Acos(1 - sqd/2)
+	if r < h3Epsilon {
+		return face, 0, 0
+	}
+	center := h3FaceCenterGeo[face]
+	azimuth := math.Atan2(
+		math.Cos(lat)*math.Sin(lon-center[1]),
+		math.Cos(center[0])*math.Sin(lat)-math.Sin(center[0])*math.Cos(lat)*math.Cos(lon-center[1]),
+	)
+	theta := h3PosAngle(h3FaceAxisAz[face] - h3PosAngle(azimuth))
+	if h3IsClassIII(res) {
+		theta = h3PosAngle(theta - h3Ap7RotRads)
+	}
+
+	r = math.Tan(r) / h3Res0UGnomonic
+	for i := 0; i < res; i++ {
+		r *= math.Sqrt(7)
+	}
+	return face, r * math.Cos(theta), r * math.Sin(theta)
+}
+
+// h3Hex2dToCoordIJK returns the grid cell containing a 2D face position
+func h3Hex2dToCoordIJK(x, y float64) h3CoordIJK {
+	a1, a2 := math.Abs(x), math.Abs(y)
+	x2 := a2 / math.Sin(math.Pi/3)
+	x1 := a1 + x2/2
+	m1, m2 := int(x1), int(x2)
+	r1, r2 := x1-float64(m1), x2-float64(m2)
+
+	var c h3CoordIJK
+	switch {
+	case r1 < 0.5 && r1 < 1.0/3:
+		c.i, c.j = m1, m2
+		if r2 >= (1+r1)/2 {
+			c.j = m2 + 1
+		}
+	case r1 < 0.5:
+		c.i, c.j = m1, m2
+		if r2 >= 1-r1 {
+			c.j = m2 + 1
+		}
+		if 1-r1 <= r2 && r2 < 2*r1 {
+			c.i = m1 + 1
+		}
+	case r1 < 2.0/3:
+		c.i, c.j = m1+1, m2
+		if r2 >= 1-r1 {
+			c.j = m2 + 1
+		}
+		if 2*r1-1 < r2 && r2 < 1-r1 {
+			c.i = m1
+		}
+	default:
+		c.i, c.j = m1+1, m2
+		if r2 >= r1/2 {
+			c.j = m2 + 1
+		}
+	}
+
+	// fold across the axes if necessary
+	if x < 0 {
+		if c.j%2 == 0 {
+			c.i -= 2 * (c.i - c.j/2)
+		} else {
+			c.i -= 2*(c.i-(c.j+1)/2) + 1
+		}
+	}
+	if y < 0 {
+		c.i -= (2*c.j + 1) / 2
+		c.j = -c.j
+	}
+	c.normalize()
+	return c
+}
+
+// h3FaceIJKToCell walks a grid cell of a face up to its base cell, recording
+// the digit of each resolution, and rotates the digits into the base cell's
+// frame. It returns 0 if the cell falls off the face's base cell table.
+func h3FaceIJKToCell(face int, c h3CoordIJK, res int) uint64 {
+	h := h3InitIndex | uint64(res)<<52
+	for r := res - 1; r >= 0; r-- {
+		last := c
+		var center h3CoordIJK
+		if h3IsClassIII(r + 1) {
+			c.upAp7()
+			center = c
+			center.downAp7()
+		} else {
+			c.upAp7r()
+			center = c
+			center.downAp7r()
+		}
+		diff := h3CoordIJK{last.i - center.i, last.j - center.j, last.k - center.k}
+		diff.normalize()
+		h = h3SetDigit(h, r+1, diff.unitDigit())
+	}
+
+	if c.i > 2 || c.j > 2 || c.k > 2 {
+		return 0
+	}
+	fbc := h3FaceBaseCells[face][c.i][c.j][c.k]
+	h |= uint64(fbc.baseCell) << 45
+
+	baseCell := h3BaseCells[fbc.baseCell]
+	if !baseCell.pentagon {
+		for n := 0; n < fbc.ccwRot60; n++ {
+			h = h3Rotate60(h, res, h3DigitCCW)
+		}
+		return h
+	}
+	// a pentagon has no k axes sub-sequence, rotate it out of the deleted one
+	if h3LeadingDigit(h, res) == 1 {
+		if baseCell.cwOffset[0] == face || baseCell.cwOffset[1] == face {
+			h = h3Rotate60(h, res, h3DigitCW)
+		} else {
+			h = h3Rotate60(h, res, h3DigitCCW)
+		}
+	}
+	for n := 0; n < fbc.ccwRot60; n++ {
+		h = h3RotatePent60ccw(h, res)
+	}
+	return h
+}
+
+// h3IsClassIII reports whether res is rotated against the base grid, i.e. odd
+func h3IsClassIII(res int) bool {
+	return res%2 == 1
+}
+
+func h3PosAngle(rads float64) float64 {
+	if rads < 0 {
+		rads += 2 * math.Pi
+	}
+	if rads >= 2*math.Pi {
+		rads -= 2 * math.Pi
+	}
+	return rads
+}
+
+func h3PointOf(lat, lon float64) [3]float64 {
+	return [3]float64{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
+
+func (c *h3CoordIJK) normalize() {
+	if c.i < 0 {
+		c.j -= c.i
+		c.k -= c.i
+		c.i = 0
+	}
+	if c.j < 0 {
+		c.i -= c.j
+		c.k -= c.j
+		c.j = 0
+	}
+	if c.k < 0 {
+		c.i -= c.k
+		c.j -= c.k
+		c.k = 0
+	}
+	min := c.i
+	if c.j < min {
+		min = c.j
+	}
+	if c.k < min {
+		min = c.k
+	}
+	c.i -= min
+	c.j -= min
+	c.k -= min
+}
+
+// upAp7 moves c to the parent cell, one Class III resolution up
+func (c *h3CoordIJK) upAp7() {
+	i, j := float64(c.i-c.k), float64(c.j-c.k)
+	c.i = int(math.Round((3*i - j) / 7))
+	c.j = int(math.Round((i + 2*j) / 7))
+	c.k = 0
+	c.normalize()
+}
+
+// upAp7r moves c to the parent cell, one Class II resolution up
+func (c *h3CoordIJK) upAp7r() {
+	i, j := float64(c.i-c.k), float64(c.j-c.k)
+	c.i = int(math.Round((2*i + j) / 7))
+	c.j = int(math.Round((3*j - i) / 7))
+	c.k = 0
+	c.normalize()
+}
+
+// downAp7 moves c to the center child, one Class III resolution down
+func (c *h3CoordIJK) downAp7() {
+	*c = h3CoordIJK{3*c.i + c.j, 3*c.j + c.k, c.i + 3*c.k}
+	c.normalize()
+}
+
+// downAp7r moves c to the center child, one Class II resolution down
+func (c *h3CoordIJK) downAp7r() {
+	*c = h3CoordIJK{3*c.i + c.k, c.i + 3*c.j, c.j + 3*c.k}
+	c.normalize()
+}
+
+// unitDigit returns the digit of a normalized unit vector: the center is 0,
+// and the i, j and k bits of the digit tell the axes the vector points along.
+func (c h3CoordIJK) unitDigit() int {
+	return c.i<<2 | c.j<<1 | c.k
+}
+
+// h3DigitCCW and h3DigitCW rotate a digit 60° counter-clockwise and clockwise
+var (
+	h3DigitCCW = [7]int{0, 5, 3, 1, 6, 4, 2}
+	h3DigitCW  = [7]int{0, 3, 6, 2, 5, 1, 4}
+)
+
+func h3DigitOffset(r int) uint {
+	return uint(h3MaxResolution-r) * 3
+}
+
+func h3GetDigit(h uint64, r int) int {
+	return int(h >> h3DigitOffset(r) & 7)
+}
+
+func h3SetDigit(h uint64, r, digit int) uint64 {
+	return h&^(7<<h3DigitOffset(r)) | uint64(digit)<<h3DigitOffset(r)
+}
+
+// h3LeadingDigit returns the first non-zero digit, or 0 for a center child
+func h3LeadingDigit(h uint64, res int) int {
+	for r := 1; r <= res; r++ {
+		if digit := h3GetDigit(h, r); digit != 0 {
+			return digit
+		}
+	}
+	return 0
+}
+
+// h3Rotate60 rotates every digit of h by the given digit rotation
+func h3Rotate60(h uint64, res int, rotation [7]int) uint64 {
+	for r := 1; r <= res; r++ {
+		h = h3SetDigit(h, r, rotation[h3GetDigit(h, r)])
+	}
+	return h
+}
+
+// h3RotatePent60ccw rotates the digits of a pentagon's descendant 60° ccw,
+// rotating once more if that leads into the deleted k axes sub-sequence.
+func h3RotatePent60ccw(h uint64, res int) uint64 {
+	foundFirst := false
+	for r := 1; r <= res; r++ {
+		h = h3SetDigit(h, r, h3DigitCCW[h3GetDigit(h, r)])
+		if !foundFirst && h3GetDigit(h, r) != 0 {
+			foundFirst = true
+			if h3LeadingDigit(h, res) == 1 {
+				h = h3Rotate60(h, res, h3DigitCCW)
+			}
+		}
+	}
+	return h
+}
+
+// h3FaceCenterPoints holds the face centers as unit vectors
+var h3FaceCenterPoints = func() [20][3]float64 {
+	var points [20][3]float64
+	for f, center := range h3FaceCenterGeo {
+		points[f] = h3PointOf(center[0], center[1])
+	}
+	return points
+}()
+
+// h3FaceCenterGeo holds the lat/lon, in radians, of each face center
+var h3FaceCenterGeo = [20][2]float64{
+	{0.803582649718989942, 1.248397419617396099},
+	{1.307747883455638156, 2.536945009877921159},
+	{1.054751253523952054, -1.347517358900396623},
+	{0.600191595538186799, -0.450603909469755746},
+	{0.491715428198773866, 0.401988202911306943},
+	{0.172745327415618701, 1.678146885280433686},
+	{0.605929321571350690, 2.953923329812411617},
+	{0.427370518328979641, -1.888876200336285401},
+	{-0.079066118549212831, -0.733429513380867741},
+	{-0.230961644455383637, 0.506495587332349035},
+	{0.079066118549212831, 2.408163140208925497},
+	{0.230961644455383637, -2.635097066257444203},
+	{-0.172745327415618701, -1.463445768309359553},
+	{-0.605929321571350690, -0.187669323777381622},
+	{-0.427370518328979641, 1.252716453253507838},
+	{-0.600191595538186799, 2.690988744120037492},
+	{-0.491715428198773866, -2.739604450678486295},
+	{-0.803582649718989942, -1.893195233972397139},
+	{-1.307747883455638156, -0.604647643711872080},
+	{-1.054751253523952054, 1.794075294689396615},
+}
+
+// h3FaceAxisAz holds the azimuth, in radians, of each face's i axis
+var h3FaceAxisAz = [20]float64{
+	5.619958268523939882,
+	5.760339081714187279,
+	0.780213654393430055,
+	0.430469363979999913,
+	6.130269123335111400,
+	2.692877706530642877,
+	2.982963003477243874,
+	3.532912002790141181,
+	3.494305004259568154,
+	3.003214169499538391,
+	5.930472956509811562,
+	0.138378484090254847,
+	0.448714947059150361,
+	0.158629650112549365,
+	5.891865957979238535,
+	2.711123289609793325,
+	3.294508837434268316,
+	3.804819692245439833,
+	3.664438879055192436,
+	2.361378999196363184,
+}
+
+// h3BaseCells holds the home face and position of each base cell
+var h3BaseCells = [122]h3BaseCell{
+	{1, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 0
+	{2, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},   // 1
+	{1, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 2
+	{2, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 3
+	{0, h3CoordIJK{2, 0, 0}, true, [2]int{-1, -1}},  // 4
+	{1, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},   // 5
+	{1, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 6
+	{2, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 7
+	{0, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 8
+	{2, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 9
+	{1, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 10
+	{1, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},   // 11
+	{3, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 12
+	{3, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},   // 13
+	{11, h3CoordIJK{2, 0, 0}, true, [2]int{2, 6}},   // 14
+	{4, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 15
+	{0, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 16
+	{6, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 17
+	{0, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 18
+	{2, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},   // 19
+	{7, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 20
+	{2, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 21
+	{0, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},   // 22
+	{6, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 23
+	{10, h3CoordIJK{2, 0, 0}, true, [2]int{1, 5}},   // 24
+	{6, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 25
+	{3, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 26
+	{11, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 27
+	{4, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},   // 28
+	{3, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 29
+	{0, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},   // 30
+	{4, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 31
+	{5, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 32
+	{0, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 33
+	{7, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 34
+	{11, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},  // 35
+	{7, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 36
+	{10, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 37
+	{12, h3CoordIJK{2, 0, 0}, true, [2]int{3, 7}},   // 38
+	{6, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},   // 39
+	{7, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},   // 40
+	{4, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 41
+	{3, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 42
+	{3, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},   // 43
+	{4, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 44
+	{6, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 45
+	{11, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 46
+	{8, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 47
+	{5, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 48
+	{14, h3CoordIJK{2, 0, 0}, true, [2]int{0, 9}},   // 49
+	{5, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 50
+	{12, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 51
+	{10, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},  // 52
+	{4, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},   // 53
+	{12, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},  // 54
+	{7, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 55
+	{11, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 56
+	{10, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 57
+	{13, h3CoordIJK{2, 0, 0}, true, [2]int{4, 8}},   // 58
+	{10, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 59
+	{11, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 60
+	{9, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 61
+	{8, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},   // 62
+	{6, h3CoordIJK{2, 0, 0}, true, [2]int{11, 15}},  // 63
+	{8, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 64
+	{9, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},   // 65
+	{14, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 66
+	{5, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},   // 67
+	{16, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},  // 68
+	{8, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},   // 69
+	{5, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 70
+	{12, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 71
+	{7, h3CoordIJK{2, 0, 0}, true, [2]int{12, 16}},  // 72
+	{12, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 73
+	{10, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 74
+	{9, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},   // 75
+	{13, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 76
+	{16, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 77
+	{15, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},  // 78
+	{15, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 79
+	{16, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 80
+	{14, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},  // 81
+	{13, h3CoordIJK{1, 1, 0}, false, [2]int{0, 0}},  // 82
+	{5, h3CoordIJK{2, 0, 0}, true, [2]int{10, 19}},  // 83
+	{8, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 84
+	{14, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 85
+	{9, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},   // 86
+	{14, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 87
+	{17, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 88
+	{12, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 89
+	{16, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 90
+	{17, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},  // 91
+	{15, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 92
+	{16, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},  // 93
+	{9, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},   // 94
+	{15, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 95
+	{13, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 96
+	{8, h3CoordIJK{2, 0, 0}, true, [2]int{13, 17}},  // 97
+	{13, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 98
+	{17, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},  // 99
+	{19, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 100
+	{14, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 101
+	{19, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},  // 102
+	{17, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 103
+	{13, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 104
+	{17, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 105
+	{16, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 106
+	{9, h3CoordIJK{2, 0, 0}, true, [2]int{14, 18}},  // 107
+	{15, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},  // 108
+	{15, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 109
+	{18, h3CoordIJK{0, 1, 1}, false, [2]int{0, 0}},  // 110
+	{18, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 111
+	{19, h3CoordIJK{0, 0, 1}, false, [2]int{0, 0}},  // 112
+	{17, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 113
+	{19, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 114
+	{18, h3CoordIJK{0, 1, 0}, false, [2]int{0, 0}},  // 115
+	{18, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},  // 116
+	{17, h3CoordIJK{2, 0, 0}, true, [2]int{-1, -1}}, // 117
+	{19, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 118
+	{18, h3CoordIJK{0, 0, 0}, false, [2]int{0, 0}},  // 119
+	{19, h3CoordIJK{1, 0, 1}, false, [2]int{0, 0}},  // 120
+	{18, h3CoordIJK{1, 0, 0}, false, [2]int{0, 0}},  // 121
+}
+
+// h3FaceBaseCells holds, for each face and resolution 0 i, j, k position,
+// the base cell there and its ccw rotation
+var h3FaceBaseCells = [20][3][3][3]h3FaceBaseCell{
+	{ // face 0
+		{
+			{{16, 0}, {18, 0}, {24, 0}},
+			{{33, 0}, {30, 0}, {32, 3}},
+			{{49, 1}, {48, 3}, {50, 3}},
+		},
+		{
+			{{8, 0}, {5, 5}, {10, 5}},
+			{{22, 0}, {16, 0}, {18, 0}},
+			{{41, 1}, {33, 0}, {30, 0}},
+		},
+		{
+			{{4, 0}, {0, 5}, {2, 5}},
+			{{15, 1}, {8, 0}, {5, 5}},
+			{{31, 1}, {22, 0}, {16, 0}},
+		},
+	},
+	{ // face 1
+		{
+			{{2, 0}, {6, 0}, {14, 0}},
+			{{10, 0}, {11, 0}, {17, 3}},
+			{{24, 1}, {23, 3}, {25, 3}},
+		},
+		{
+			{{0, 0}, {1, 5}, {9, 5}},
+			{{5, 0}, {2, 0}, {6, 0}},
+			{{18, 1}, {10, 0}, {11, 0}},
+		},
+		{
+			{{4, 1}, {3, 5}, {7, 5}},
+			{{8, 1}, {0, 0}, {1, 5}},
+			{{16, 1}, {5, 0}, {2, 0}},
+		},
+	},
+	{ // face 2
+		{
+			{{7, 0}, {21, 0}, {38, 0}},
+			{{9, 0}, {19, 0}, {34, 3}},
+			{{14, 1}, {20, 3}, {36, 3}},
+		},
+		{
+			{{3, 0}, {13, 5}, {29, 5}},
+			{{1, 0}, {7, 0}, {21, 0}},
+			{{6, 1}, {9, 0}, {19, 0}},
+		},
+		{
+			{{4, 2}, {12, 5}, {26, 5}},
+			{{0, 1}, {3, 0}, {13, 5}},
+			{{2, 1}, {1, 0}, {7, 0}},
+		},
+	},
+	{ // face 3
+		{
+			{{26, 0}, {42, 0}, {58, 0}},
+			{{29, 0}, {43, 0}, {62, 3}},
+			{{38, 1}, {47, 3}, {64, 3}},
+		},
+		{
+			{{12, 0}, {28, 5}, {44, 5}},
+			{{13, 0}, {26, 0}, {42, 0}},
+			{{21, 1}, {29, 0}, {43, 0}},
+		},
+		{
+			{{4, 3}, {15, 5}, {31, 5}},
+			{{3, 1}, {12, 0}, {28, 5}},
+			{{7, 1}, {13, 0}, {26, 0}},
+		},
+	},
+	{ // face 4
+		{
+			{{31, 0}, {41, 0}, {49, 0}},
+			{{44, 0}, {53, 0}, {61, 3}},
+			{{58, 1}, {65, 3}, {75, 3}},
+		},
+		{
+			{{15, 0}, {22, 5}, {33, 5}},
+			{{28, 0}, {31, 0}, {41, 0}},
+			{{42, 1}, {44, 0}, {53, 0}},
+		},
+		{
+			{{4, 4}, {8, 5}, {16, 5}},
+			{{12, 1}, {15, 0}, {22, 5}},
+			{{26, 1}, {28, 0}, {31, 0}},
+		},
+	},
+	{ // face 5
+		{
+			{{50, 0}, {48, 0}, {49, 3}},
+			{{32, 0}, {30, 3}, {33, 3}},
+			{{24, 3}, {18, 3}, {16, 3}},
+		},
+		{
+			{{70, 0}, {67, 0}, {66, 3}},
+			{{52, 3}, {50, 0}, {48, 0}},
+			{{37, 3}, {32, 0}, {30, 3}},
+		},
+		{
+			{{83, 0}, {87, 3}, {85, 3}},
+			{{74, 3}, {70, 0}, {67, 0}},
+			{{57, 3}, {52, 3}, {50, 0}},
+		},
+	},
+	{ // face 6
+		{
+			{{25, 0}, {23, 0}, {24, 3}},
+			{{17, 0}, {11, 3}, {10, 3}},
+			{{14, 3}, {6, 3}, {2, 3}},
+		},
+		{
+			{{45, 0}, {39, 0}, {37, 3}},
+			{{35, 3}, {25, 0}, {23, 0}},
+			{{27, 3}, {17, 0}, {11, 3}},
+		},
+		{
+			{{63, 0}, {59, 3}, {57, 3}},
+			{{56, 3}, {45, 0}, {39, 0}},
+			{{46, 3}, {35, 3}, {25, 0}},
+		},
+	},
+	{ // face 7
+		{
+			{{36, 0}, {20, 0}, {14, 3}},
+			{{34, 0}, {19, 3}, {9, 3}},
+			{{38, 3}, {21, 3}, {7, 3}},
+		},
+		{
+			{{55, 0}, {40, 0}, {27, 3}},
+			{{54, 3}, {36, 0}, {20, 0}},
+			{{51, 3}, {34, 0}, {19, 3}},
+		},
+		{
+			{{72, 0}, {60, 3}, {46, 3}},
+			{{73, 3}, {55, 0}, {40, 0}},
+			{{71, 3}, {54, 3}, {36, 0}},
+		},
+	},
+	{ // face 8
+		{
+			{{64, 0}, {47, 0}, {38, 3}},
+			{{62, 0}, {43, 3}, {29, 3}},
+			{{58, 3}, {42, 3}, {26, 3}},
+		},
+		{
+			{{84, 0}, {69, 0}, {51, 3}},
+			{{82, 3}, {64, 0}, {47, 0}},
+			{{76, 3}, {62, 0}, {43, 3}},
+		},
+		{
+			{{97, 0}, {89, 3}, {71, 3}},
+			{{98, 3}, {84, 0}, {69, 0}},
+			{{96, 3}, {82, 3}, {64, 0}},
+		},
+	},
+	{ // face 9
+		{
+			{{75, 0}, {65, 0}, {58, 3}},
+			{{61, 0}, {53, 3}, {44, 3}},
+			{{49, 3}, {41, 3}, {31, 3}},
+		},
+		{
+			{{94, 0}, {86, 0}, {76, 3}},
+			{{81, 3}, {75, 0}, {65, 0}},
+			{{66, 3}, {61, 0}, {53, 3}},
+		},
+		{
+			{{107, 0}, {104, 3}, {96, 3}},
+			{{101, 3}, {94, 0}, {86, 0}},
+			{{85, 3}, {81, 3}, {75, 0}},
+		},
+	},
+	{ // face 10
+		{
+			{{57, 0}, {59, 0}, {63, 3}},
+			{{74, 0}, {78, 3}, {79, 3}},
+			{{83, 3}, {92, 3}, {95, 3}},
+		},
+		{
+			{{37, 0}, {39, 3}, {45, 3}},
+			{{52, 0}, {57, 0}, {59, 0}},
+			{{70, 3}, {74, 0}, {78, 3}},
+		},
+		{
+			{{24, 0}, {23, 3}, {25, 3}},
+			{{32, 3}, {37, 0}, {39, 3}},
+			{{50, 3}, {52, 0}, {57, 0}},
+		},
+	},
+	{ // face 11
+		{
+			{{46, 0}, {60, 0}, {72, 3}},
+			{{56, 0}, {68, 3}, {80, 3}},
+			{{63, 3}, {77, 3}, {90, 3}},
+		},
+		{
+			{{27, 0}, {40, 3}, {55, 3}},
+			{{35, 0}, {46, 0}, {60, 0}},
+			{{45, 3}, {56, 0}, {68, 3}},
+		},
+		{
+			{{14, 0}, {20, 3}, {36, 3}},
+			{{17, 3}, {27, 0}, {40, 3}},
+			{{25, 3}, {35, 0}, {46, 0}},
+		},
+	},
+	{ // face 12
+		{
+			{{71, 0}, {89, 0}, {97, 3}},
+			{{73, 0}, {91, 3}, {103, 3}},
+			{{72, 3}, {88, 3}, {105, 3}},
+		},
+		{
+			{{51, 0}, {69, 3}, {84, 3}},
+			{{54, 0}, {71, 0}, {89, 0}},
+			{{55, 3}, {73, 0}, {91, 3}},
+		},
+		{
+			{{38, 0}, {47, 3}, {64, 3}},
+			{{34, 3}, {51, 0}, {69, 3}},
+			{{36, 3}, {54, 0}, {71, 0}},
+		},
+	},
+	{ // face 13
+		{
+			{{96, 0}, {104, 0}, {107, 3}},
+			{{98, 0}, {110, 3}, {115, 3}},
+			{{97, 3}, {111, 3}, {119, 3}},
+		},
+		{
+			{{76, 0}, {86, 3}, {94, 3}},
+			{{82, 0}, {96, 0}, {104, 0}},
+			{{84, 3}, {98, 0}, {110, 3}},
+		},
+		{
+			{{58, 0}, {65, 3}, {75, 3}},
+			{{62, 3}, {76, 0}, {86, 3}},
+			{{64, 3}, {82, 0}, {96, 0}},
+		},
+	},
+	{ // face 14
+		{
+			{{85, 0}, {87, 0}, {83, 3}},
+			{{101, 0}, {102, 3}, {100, 3}},
+			{{107, 3}, {112, 3}, {114, 3}},
+		},
+		{
+			{{66, 0}, {67, 3}, {70, 3}},
+			{{81, 0}, {85, 0}, {87, 0}},
+			{{94, 3}, {101, 0}, {102, 3}},
+		},
+		{
+			{{49, 0}, {48, 3}, {50, 3}},
+			{{61, 3}, {66, 0}, {67, 3}},
+			{{75, 3}, {81, 0}, {85, 0}},
+		},
+	},
+	{ // face 15
+		{
+			{{95, 0}, {92, 0}, {83, 0}},
+			{{79, 0}, {78, 0}, {74, 3}},
+			{{63, 1}, {59, 3}, {57, 3}},
+		},
+		{
+			{{109, 0}, {108, 0}, {100, 5}},
+			{{93, 1}, {95, 0}, {92, 0}},
+			{{77, 1}, {79, 0}, {78, 0}},
+		},
+		{
+			{{117, 2}, {118, 5}, {114, 5}},
+			{{106, 1}, {109, 0}, {108, 0}},
+			{{90, 1}, {93, 1}, {95, 0}},
+		},
+	},
+	{ // face 16
+		{
+			{{90, 0}, {77, 0}, {63, 0}},
+			{{80, 0}, {68, 0}, {56, 3}},
+			{{72, 1}, {60, 3}, {46, 3}},
+		},
+		{
+			{{106, 0}, {93, 0}, {79, 5}},
+			{{99, 1}, {90, 0}, {77, 0}},
+			{{88, 1}, {80, 0}, {68, 0}},
+		},
+		{
+			{{117, 1}, {109, 5}, {95, 5}},
+			{{113, 1}, {106, 0}, {93, 0}},
+			{{105, 1}, {99, 1}, {90, 0}},
+		},
+	},
+	{ // face 17
+		{
+			{{105, 0}, {88, 0}, {72, 0}},
+			{{103, 0}, {91, 0}, {73, 3}},
+			{{97, 1}, {89, 3}, {71, 3}},
+		},
+		{
+			{{113, 0}, {99, 0}, {80, 5}},
+			{{116, 1}, {105, 0}, {88, 0}},
+			{{111, 1}, {103, 0}, {91, 0}},
+		},
+		{
+			{{117, 0}, {106, 5}, {90, 5}},
+			{{121, 1}, {113, 0}, {99, 0}},
+			{{119, 1}, {116, 1}, {105, 0}},
+		},
+	},
+	{ // face 18
+		{
+			{{119, 0}, {111, 0}, {97, 0}},
+			{{115, 0}, {110, 0}, {98, 3}},
+			{{107, 1}, {104, 3}, {96, 3}},
+		},
+		{
+			{{121, 0}, {116, 0}, {103, 5}},
+			{{120, 1}, {119, 0}, {111, 0}},
+			{{112, 1}, {115, 0}, {110, 0}},
+		},
+		{
+			{{117, 4}, {113, 5}, {105, 5}},
+			{{118, 1}, {121, 0}, {116, 0}},
+			{{114, 1}, {120, 1}, {119, 0}},
+		},
+	},
+	{ // face 19
+		{
+			{{114, 0}, {112, 0}, {107, 0}},
+			{{100, 0}, {102, 0}, {101, 3}},
+			{{83, 1}, {87, 3}, {85, 3}},
+		},
+		{
+			{{118, 0}, {120, 0}, {115, 5}},
+			{{108, 1}, {114, 0}, {112, 0}},
+			{{92, 1}, {100, 0}, {102, 0}},
+		},
+		{
+			{{117, 3}, {121, 5}, {119, 5}},
+			{{109, 1}, {118, 0}, {120, 0}},
+			{{95, 1}, {108, 1}, {114, 0}},
+		},
+	},
+}
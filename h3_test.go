@@ -0,0 +1,110 @@
+package zipcodes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestH3CellString(t *testing.T) {
+	cases := []struct {
+		Lat        float64
+		Lon        float64
+		Resolution int
+		Expected   string
+	}{
+		{0, 0, 0, "8075fffffffffff"},
+		{0, 0, 5, "85754e67fffffff"},
+		{37.3615593, -122.0553238, 7, "87283472bffffff"},
+		{37.769377, -122.388903, 9, "89283082e73ffff"},
+		{40.689167, -74.044444, 10, "8a2a1072b59ffff"},
+		{64.7000001, 10.5361991, 0, "8009fffffffffff"},
+		{64.7000001, 10.5361991, 15, "8f0800000000000"},
+	}
+	for _, c := range cases {
+		if cell := h3CellString(c.Lat, c.Lon, c.Resolution); cell != c.Expected {
+			t.Errorf("Unexpected H3 cell of %v/%v at resolution %d. Got %s, want %s", c.Lat, c.Lon, c.Resolution, cell, c.Expected)
+		}
+	}
+}
+
+func TestH3CellOf(t *testing.T) {
+	cases := []struct {
+		ZipCode    string
+		Resolution int
+		Expected   string
+	}{
+		{"01945", 7, "871f1b8d3ffffff"},
+		{"20457", 9, "891f15ad2abffff"},
+		{"20457", 0, "801ffffffffffff"},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		cell, err := zipcodesDataset.H3CellOf(c.ZipCode, c.Resolution)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for H3 cell %v", err)
+		}
+		if cell != c.Expected {
+			t.Errorf("Unexpected H3 cell of %s at resolution %d. Got %s, want %s", c.ZipCode, c.Resolution, cell, c.Expected)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.H3CellOf("01945", 16)
+	if err == nil || err.Error() != "zipcodes: H3 resolution must be between 0 and 15" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: H3 resolution must be between 0 and 15")
+	}
+	_, err = zipcodesDataset.H3CellOf("XYZ", 7)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+	blankDataset, err := New("datasets/blank_coordinates_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	_, err = blankDataset.H3CellOf("96799", 7)
+	if err == nil || err.Error() != "zipcodes: zipcode 96799 has no coordinates" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 96799 has no coordinates")
+	}
+}
+
+func TestZipcodesByH3Cell(t *testing.T) {
+	cases := []struct {
+		Dataset    string
+		Opts       []Option
+		Resolution int
+		Expected   map[string][]string
+	}{
+		{"datasets/valid_dataset.txt", nil, 0, map[string][]string{
+			"801ffffffffffff": {"01945", "03058", "19053", "20457", "22525", "34134", "87787", "94051"},
+		}},
+		{"datasets/valid_dataset.txt", nil, 4, map[string][]string{
+			"841e321ffffffff": {"94051"},
+			"841f021ffffffff": {"19053"},
+			"841f15bffffffff": {"20457", "22525"},
+			"841f1b1ffffffff": {"01945"},
+			"841f1bbffffffff": {"03058"},
+			"841f8c7ffffffff": {"87787"},
+			"841facbffffffff": {"34134"},
+		}},
+		{"datasets/blank_coordinates_dataset.txt", []Option{WithBlankCoordinates()}, 3, map[string][]string{
+			"831f19fffffffff": {"03058"},
+			"831f1bfffffffff": {"01945"},
+		}},
+		{"datasets/valid_dataset.txt", nil, -1, map[string][]string{}},
+		{"datasets/valid_dataset.txt", nil, 16, map[string][]string{}},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset, c.Opts...)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+			continue
+		}
+		cells := zipcodesDataset.ZipcodesByH3Cell(c.Resolution)
+		if reflect.DeepEqual(cells, c.Expected) != true {
+			t.Errorf("Unexpected H3 cells of %s at resolution %d. Got %v, want %v", c.Dataset, c.Resolution, cells, c.Expected)
+		}
+	}
+}
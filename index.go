@@ -0,0 +1,274 @@
+package zipcodes
+
+import (
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"sync"
+)
+
+// indexCellSize is the size in degrees of the cells of the spatial index
+const indexCellSize = 1.0
+
+// gridCell identifies a cell of the spatial index
+type gridCell struct {
+	Lat int
+	Lon int
+}
+
+// grid buckets the locations of a dataset by the cell they fall in, with
+// their trigonometry precomputed
+type grid struct {
+	cells map[gridCell][]point
+	count int
+}
+
+// spatialIndex holds the grid of a dataset, built once on demand
+type spatialIndex struct {
+	mu   sync.Mutex
+	grid *grid
+}
+
+// cellOf returns the index cell a lat/lon falls in
+func cellOf(lat, lon float64) gridCell {
+	return gridCell{
+		Lat: int(math.Floor(lat / indexCellSize)),
+		Lon: normalizeLonCell(int(math.Floor(lon / indexCellSize))),
+	}
+}
+
+// normalizeLonCell wraps a longitude cell number around the antimeridian
+func normalizeLonCell(cell int) int {
+	cells := int(360 / indexCellSize)
+	first := int(-180 / indexCellSize)
+	return ((cell-first)%cells+cells)%cells + first
+}
+
+// buildGrid buckets every location with coordinates of the dataset. The
+// cells are sized by a first pass so the points are copied only once, which
+// halves the build time compared to growing the cells while appending.
+func buildGrid(zc *Zipcodes) *grid {
+	sizes := make(map[gridCell]int)
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			sizes[cellOf(elm.Lat, elm.Lon)]++
+		}
+		return true
+	})
+
+	g := &grid{cells: make(map[gridCell][]point, len(sizes))}
+	for cell, size := range sizes {
+		g.cells[cell] = make([]point, 0, size)
+	}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		g.count++
+		if elm.HasCoordinates() {
+			cell := cellOf(elm.Lat, elm.Lon)
+			g.cells[cell] = append(g.cells[cell], newPoint(elm))
+		}
+		return true
+	})
+	return g
+}
+
+// WarmUp builds the spatial index used by the radius queries, so its cost is
+// paid at boot instead of on the first query. SaveIndex and LoadIndex persist
+// it between runs. The index is a snapshot of the
+// dataset: entries added to or removed from DatasetList afterwards trigger a
+// rebuild on the next query, entries modified in place do not. For a struct
+// created with NewFromSource the snapshot is never refreshed on its own, see
+// InvalidateIndex.
+func (zc *Zipcodes) WarmUp() {
+	if zc.index == nil {
+		zc.index = &spatialIndex{}
+	}
+	zc.spatialGrid()
+}
+
+// IndexReady reports whether the spatial index has already been built
+func (zc *Zipcodes) IndexReady() bool {
+	if zc.index == nil {
+		return false
+	}
+	zc.index.mu.Lock()
+	defer zc.index.mu.Unlock()
+	return zc.index.grid != nil
+}
+
+// InvalidateIndex drops the spatial index and the sorted zipcodes so they are
+// rebuilt from the current data on the next query that needs them. Call it
+// after modifying entries of DatasetList in place, or after the data behind a
+// LocationSource changed, since radius, nearest and range queries otherwise
+// keep answering from the snapshot taken when the indexes were built.
+func (zc *Zipcodes) InvalidateIndex() {
+	if zc.index != nil {
+		zc.index.mu.Lock()
+		zc.index.grid = nil
+		zc.index.mu.Unlock()
+	}
+	if zc.keys != nil {
+		zc.keys.mu.Lock()
+		zc.keys.keys = nil
+		zc.keys.mu.Unlock()
+	}
+}
+
+// spatialGrid returns the spatial index, building it if needed. It returns nil
+// when the struct has no index, e.g. when it was not created by this package.
+func (zc *Zipcodes) spatialGrid() *grid {
+	if zc.index == nil {
+		return nil
+	}
+	zc.index.mu.Lock()
+	defer zc.index.mu.Unlock()
+	if zc.index.grid == nil || (zc.source == nil && zc.index.grid.count != len(zc.DatasetList)) {
+		zc.index.grid = buildGrid(zc)
+	}
+	return zc.index.grid
+}
+
+// rangeNear calls fn for every location with coordinates that may be within
+// maxRadius of the center, until fn returns false. Callers must still check
+// the actual distance. Without a spatial index, every location is visited.
+func (zc *Zipcodes) rangeNear(center point, maxRadius, earthRadius float64, fn func(point) bool) {
+	g := zc.spatialGrid()
+	if g == nil {
+		zc.Range(func(elm ZipCodeLocation) bool {
+			if !elm.HasCoordinates() {
+				return true
+			}
+			return fn(newPoint(elm))
+		})
+		return
+	}
+
+	lat, lon := center.location.Lat, center.location.Lon
+	// DistanceBetweenPoints rounds to two decimals, widen the search so
+	// rounded distances just below maxRadius are not missed
+	angularRadius := radiansToDegrees((maxRadius + 0.01) / earthRadius)
+	if angularRadius >= 90 || lat+angularRadius >= 90 || lat-angularRadius <= -90 {
+		for _, points := range g.cells {
+			for _, p := range points {
+				if !fn(p) {
+					return
+				}
+			}
+		}
+		return
+	}
+
+	lonRadius := radiansToDegrees(math.Asin(math.Sin(degreesToRadians(angularRadius)) / math.Cos(degreesToRadians(lat))))
+	minCell := cellOf(lat-angularRadius, lon-lonRadius)
+	maxCell := cellOf(lat+angularRadius, lon+lonRadius)
+	lonCells := int(math.Floor((lon+lonRadius)/indexCellSize)) - int(math.Floor((lon-lonRadius)/indexCellSize)) + 1
+	if lonCells > int(360/indexCellSize) {
+		lonCells = int(360 / indexCellSize)
+	}
+
+	for latCell := minCell.Lat; latCell <= maxCell.Lat; latCell++ {
+		for i := 0; i < lonCells; i++ {
+			cell := gridCell{Lat: latCell, Lon: normalizeLonCell(minCell.Lon + i)}
+			for _, p := range g.cells[cell] {
+				if !fn(p) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// savedIndex is the serialized form of the spatial index
+type savedIndex struct {
+	CellSize float64
+	Count    int
+	Hash     uint64
+	Cells    map[gridCell][]string
+}
+
+// datasetHash returns a hash of the zipcodes and coordinates of the dataset
+// that does not depend on the iteration order
+func datasetHash(zc *Zipcodes) uint64 {
+	var sum uint64
+	zc.Range(func(elm ZipCodeLocation) bool {
+		h := fnv.New64a()
+		io.WriteString(h, zc.keyOf(elm))
+		binary.Write(h, binary.LittleEndian, []float64{elm.Lat, elm.Lon})
+		sum += h.Sum64()
+		return true
+	})
+	return sum
+}
+
+// SaveIndex writes the spatial index, building it first if needed, so it can
+// be restored with LoadIndex instead of being rebuilt on every start
+func (zc *Zipcodes) SaveIndex(w io.Writer) error {
+	zc.WarmUp()
+	g := zc.spatialGrid()
+	saved := savedIndex{
+		CellSize: indexCellSize,
+		Count:    g.count,
+		Hash:     datasetHash(zc),
+		Cells:    make(map[gridCell][]string, len(g.cells)),
+	}
+	for cell, points := range g.cells {
+		for _, p := range points {
+			saved.Cells[cell] = append(saved.Cells[cell], zc.keyOf(p.location))
+		}
+	}
+
+	if err := gob.NewEncoder(w).Encode(saved); err != nil {
+		return fmt.Errorf("zipcodes: error while saving index %v", err)
+	}
+	return nil
+}
+
+// LoadIndex restores a spatial index written by SaveIndex. When the saved
+// index does not match the current dataset (different zipcodes or
+// coordinates) it is discarded and the index is rebuilt from the dataset.
+// Like WarmUp, it turns the index on for a struct created with NewFromSource.
+func (zc *Zipcodes) LoadIndex(r io.Reader) error {
+	var saved savedIndex
+	if err := gob.NewDecoder(r).Decode(&saved); err != nil {
+		return fmt.Errorf("zipcodes: error while loading index %v", err)
+	}
+	if zc.index == nil {
+		zc.index = &spatialIndex{}
+	}
+
+	g := restoreGrid(zc, saved)
+	zc.index.mu.Lock()
+	defer zc.index.mu.Unlock()
+	if g == nil {
+		g = buildGrid(zc)
+	}
+	zc.index.grid = g
+	return nil
+}
+
+// restoreGrid rebuilds the grid from a saved index, or returns nil if the
+// saved index is stale
+func restoreGrid(zc *Zipcodes, saved savedIndex) *grid {
+	count := 0
+	zc.Range(func(elm ZipCodeLocation) bool {
+		count++
+		return true
+	})
+	if saved.CellSize != indexCellSize || saved.Count != count || saved.Hash != datasetHash(zc) {
+		return nil
+	}
+
+	g := &grid{cells: make(map[gridCell][]point, len(saved.Cells)), count: count}
+	for cell, zipCodes := range saved.Cells {
+		for _, zipCode := range zipCodes {
+			location, found := zc.Get(zipCode)
+			if !found {
+				return nil
+			}
+			g.cells[cell] = append(g.cells[cell], newPoint(location))
+		}
+	}
+	return g
+}
@@ -0,0 +1,309 @@
+package zipcodes
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestWarmUp(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if zipcodesDataset.IndexReady() {
+		t.Errorf("Expected the spatial index not to be built after loading")
+	}
+	zipcodesDataset.WarmUp()
+	if !zipcodesDataset.IndexReady() {
+		t.Errorf("Expected the spatial index to be built after WarmUp")
+	}
+
+	// The index is built lazily by radius queries
+	lazyDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if _, err := lazyDataset.GetZipcodesWithinKmRadius("01945", 50); err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if !lazyDataset.IndexReady() {
+		t.Errorf("Expected the spatial index to be built after a radius query")
+	}
+
+	// Or eagerly while loading
+	eagerDataset, err := New("datasets/valid_dataset.txt", WithSpatialIndex())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if !eagerDataset.IndexReady() {
+		t.Errorf("Expected the spatial index to be built while loading")
+	}
+
+	// A struct reading a source has no index until WarmUp, queries scan the source
+	sourceDataset := NewFromSource(sliceSource{zipcodesDataset.DatasetList["01945"], zipcodesDataset.DatasetList["03058"]})
+	if _, err := sourceDataset.GetZipcodesWithinKmRadius("01945", 50); err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if sourceDataset.IndexReady() {
+		t.Errorf("Expected a struct reading a source not to build the spatial index on its own")
+	}
+	sourceDataset.WarmUp()
+	if !sourceDataset.IndexReady() {
+		t.Errorf("Expected the spatial index to be built after WarmUp")
+	}
+
+	// A struct created without the package has no index until WarmUp
+	literalDataset := Zipcodes{DatasetList: zipcodesDataset.DatasetList}
+	if literalDataset.IndexReady() {
+		t.Errorf("Expected a struct literal to have no spatial index")
+	}
+	literalDataset.WarmUp()
+	if !literalDataset.IndexReady() {
+		t.Errorf("Expected the spatial index to be built after WarmUp")
+	}
+}
+
+func TestSpatialIndexMatchesLinearScan(t *testing.T) {
+	for _, dataset := range []string{"datasets/valid_dataset.txt", "datasets/antimeridian_dataset.txt"} {
+		indexed, err := New(dataset, WithSpatialIndex())
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		linear := Zipcodes{DatasetList: indexed.DatasetList}
+
+		for _, radius := range []float64{1, 50, 100, 500, 1500, 5000, 20000} {
+			for _, location := range indexed.DatasetList {
+				location := location
+				got := indexed.FindZipcodesWithinRadius(&location, radius, earthRadiusKm)
+				want := linear.FindZipcodesWithinRadius(&location, radius, earthRadiusKm)
+				sort.Strings(got)
+				sort.Strings(want)
+				if reflect.DeepEqual(got, want) != true {
+					t.Errorf("Indexed search around %s within %v km returned %v, want %v", location.ZipCode, radius, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestSpatialIndexAcrossAntimeridian(t *testing.T) {
+	zipcodesDataset, err := New("datasets/antimeridian_dataset.txt", WithSpatialIndex())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	zcList, err := zipcodesDataset.GetZipcodesWithinKmRadius("FJ02", 10)
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if reflect.DeepEqual(zcList, []string{"WS01"}) != true {
+		t.Errorf("Unexpected zipcode list returned. Got %v, want %v", zcList, []string{"WS01"})
+	}
+
+	// Adding entries to the dataset rebuilds the index
+	zipcodesDataset.DatasetList["WS03"] = ZipCodeLocation{ZipCode: "WS03", Lat: -16.8, Lon: -179.99}
+	zcList, err = zipcodesDataset.GetZipcodesWithinKmRadius("FJ02", 10)
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	sort.Strings(zcList)
+	if reflect.DeepEqual(zcList, []string{"WS01", "WS03"}) != true {
+		t.Errorf("Unexpected zipcode list returned. Got %v, want %v", zcList, []string{"WS01", "WS03"})
+	}
+}
+
+// mapSource is a LocationSource backed by a map, whose content can change
+// after the struct reading it was created
+type mapSource map[string]ZipCodeLocation
+
+func (s mapSource) Get(zipCode string) (ZipCodeLocation, bool) {
+	location, found := s[zipCode]
+	return location, found
+}
+
+func (s mapSource) Range(fn func(ZipCodeLocation) bool) {
+	for _, location := range s {
+		if !fn(location) {
+			return
+		}
+	}
+}
+
+func TestInvalidateIndex(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	source := mapSource{}
+	for zipCode, location := range zipcodesDataset.DatasetList {
+		source[zipCode] = location
+	}
+	sourceDataset := NewFromSource(source)
+	sourceDataset.WarmUp()
+
+	radiusList := func(zc *Zipcodes) []string {
+		list, err := zc.GetZipcodesWithinKmRadius("01945", 50)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s", err)
+		}
+		sort.Strings(list)
+		return list
+	}
+	rangeList := func(zc *Zipcodes) []string {
+		list := []string{}
+		for _, elm := range zc.ZipCodesInRange("01000", "01999") {
+			list = append(list, elm.ZipCode)
+		}
+		return list
+	}
+	if list := radiusList(sourceDataset); reflect.DeepEqual(list, []string{"03058"}) != true {
+		t.Errorf("Unexpected zipcode list returned. Got %v, want %v", list, []string{"03058"})
+	}
+	if list := rangeList(sourceDataset); reflect.DeepEqual(list, []string{"01945"}) != true {
+		t.Errorf("Unexpected zipcode list returned. Got %v, want %v", list, []string{"01945"})
+	}
+
+	// The indexes of a source are snapshots until they are invalidated
+	source["01946"] = ZipCodeLocation{ZipCode: "01946", PlaceName: "Ruhland", StateCode: "BB", Lat: 51.4575, Lon: 13.8664}
+	if list := radiusList(sourceDataset); reflect.DeepEqual(list, []string{"03058"}) != true {
+		t.Errorf("Unexpected zipcode list returned before invalidating. Got %v, want %v", list, []string{"03058"})
+	}
+	// a source has no key index, range queries always read its current data
+	if list := rangeList(sourceDataset); reflect.DeepEqual(list, []string{"01945", "01946"}) != true {
+		t.Errorf("Unexpected zipcode list returned before invalidating. Got %v, want %v", list, []string{"01945", "01946"})
+	}
+	sourceDataset.InvalidateIndex()
+	if sourceDataset.IndexReady() {
+		t.Errorf("Expected the spatial index to be dropped by InvalidateIndex")
+	}
+	if list := radiusList(sourceDataset); reflect.DeepEqual(list, []string{"01946", "03058"}) != true {
+		t.Errorf("Unexpected zipcode list returned after invalidating. Got %v, want %v", list, []string{"01946", "03058"})
+	}
+	if list := rangeList(sourceDataset); reflect.DeepEqual(list, []string{"01945", "01946"}) != true {
+		t.Errorf("Unexpected zipcode list returned after invalidating. Got %v, want %v", list, []string{"01945", "01946"})
+	}
+
+	// Entries modified in place are only seen once the index is invalidated
+	zipcodesDataset.WarmUp()
+	moved := zipcodesDataset.DatasetList["03058"]
+	moved.Lat, moved.Lon = 10, 10
+	zipcodesDataset.DatasetList["03058"] = moved
+	zipcodesDataset.InvalidateIndex()
+	if list := radiusList(zipcodesDataset); len(list) != 0 {
+		t.Errorf("Unexpected zipcode list returned after invalidating. Got %v", list)
+	}
+}
+
+func benchmarkFindZipcodesWithinRadius(b *testing.B, indexed bool) {
+	zipcodesDataset, err := New(writeBenchmarkDataset(b, 50000))
+	if err != nil {
+		b.Fatalf("Unexpected error while initializing struct %v", err)
+	}
+	if indexed {
+		zipcodesDataset.WarmUp()
+	} else {
+		zipcodesDataset = &Zipcodes{DatasetList: zipcodesDataset.DatasetList}
+	}
+	location, err := zipcodesDataset.Lookup("01234")
+	if err != nil {
+		b.Fatalf("Unexpected error while looking for zipcode %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zipcodesDataset.FindZipcodesWithinRadius(location, 50, earthRadiusKm)
+	}
+}
+
+func BenchmarkFindZipcodesWithinRadius(b *testing.B) {
+	benchmarkFindZipcodesWithinRadius(b, false)
+}
+
+func BenchmarkFindZipcodesWithinRadiusIndexed(b *testing.B) {
+	benchmarkFindZipcodesWithinRadius(b, true)
+}
+
+func BenchmarkWarmUp(b *testing.B) {
+	zipcodesDataset, err := New(writeBenchmarkDataset(b, 200000))
+	if err != nil {
+		b.Fatalf("Unexpected error while initializing struct %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zipcodesDataset.index = &spatialIndex{}
+		zipcodesDataset.WarmUp()
+	}
+}
+
+// gridZipCodes returns the sorted zipcodes of every cell of the grid
+func gridZipCodes(g *grid) map[gridCell][]string {
+	cells := make(map[gridCell][]string)
+	for cell, points := range g.cells {
+		for _, p := range points {
+			cells[cell] = append(cells[cell], p.location.ZipCode)
+		}
+		sort.Strings(cells[cell])
+	}
+	return cells
+}
+
+func TestSaveAndLoadIndex(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	var buf bytes.Buffer
+	if err := zipcodesDataset.SaveIndex(&buf); err != nil {
+		t.Errorf("Unexpected error while saving index %v", err)
+	}
+	saved := buf.Bytes()
+
+	// Restoring on the same dataset reuses the saved cells
+	restored, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if err := restored.LoadIndex(bytes.NewReader(saved)); err != nil {
+		t.Errorf("Unexpected error while loading index %v", err)
+	}
+	if !restored.IndexReady() {
+		t.Errorf("Expected the spatial index to be ready after LoadIndex")
+	}
+	if reflect.DeepEqual(gridZipCodes(restored.spatialGrid()), gridZipCodes(zipcodesDataset.spatialGrid())) != true {
+		t.Errorf("Restored index differs from the saved one")
+	}
+
+	// Restoring on a different dataset rebuilds the index
+	other, err := New("datasets/antimeridian_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if err := other.LoadIndex(bytes.NewReader(saved)); err != nil {
+		t.Errorf("Unexpected error while loading index %v", err)
+	}
+	if reflect.DeepEqual(gridZipCodes(other.spatialGrid()), gridZipCodes(buildGrid(other))) != true {
+		t.Errorf("Stale index was not rebuilt")
+	}
+
+	// Moving a zipcode makes the saved index stale
+	moved, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	moved.DatasetList["01945"] = ZipCodeLocation{ZipCode: "01945", Lat: 10, Lon: 10}
+	if err := moved.LoadIndex(bytes.NewReader(saved)); err != nil {
+		t.Errorf("Unexpected error while loading index %v", err)
+	}
+	zcList, err := moved.GetZipcodesWithinKmRadius("03058", 100)
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if len(zcList) != 0 {
+		t.Errorf("Unexpected zipcode list returned. Got %v", zcList)
+	}
+
+	// Failing case
+	if err := restored.LoadIndex(bytes.NewReader([]byte("not an index"))); err == nil {
+		t.Errorf("Expected an error while loading an invalid index")
+	}
+}
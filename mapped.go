@@ -0,0 +1,103 @@
+package zipcodes
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// MappedSource is a LocationSource reading a dataset file mapped in memory.
+// Only the offset of each row is kept, rows are parsed again on every Get or
+// Range, so it trades a parse per lookup for a much lower resident memory
+// than the default in-memory dataset: the mapped file is paged in and out by
+// the operating system instead of being copied into the Go heap. Use it
+// through NewFromSource and Close it once the struct is not used anymore.
+// Radius and nearest queries then parse the whole file on every call, calling
+// WarmUp speeds them up but copies every row into the spatial index, giving
+// back most of the memory saved.
+type MappedSource struct {
+	data    []byte
+	offsets map[string]int
+	options loadOptions
+	unmap   func() error
+}
+
+// OpenMappedSource maps the dataset file in memory and indexes the offset of
+// each zipcode. Every row is parsed once to validate the file, so a broken
+// file fails here as with New. The options parsing rows (WithBlankCoordinates,
+// WithMinAccuracy, WithModifiedAtColumn) apply, those building an index do
+// not. When a zipcode appears several times the last row wins.
+func OpenMappedSource(datasetPath string, opts ...Option) (*MappedSource, error) {
+	data, unmap, err := mapFile(datasetPath)
+	if err != nil {
+		return nil, fmt.Errorf("zipcodes: error while mapping file %v", err)
+	}
+
+	source := &MappedSource{data: data, offsets: make(map[string]int), options: newLoadOptions(opts), unmap: unmap}
+	// lines are copied out of the file already, and a shared interning
+	// table would be written by concurrent lookups
+	source.options.interned = nil
+	for offset := 0; offset < len(data); {
+		line, next := source.lineAt(offset)
+		location, errLine := parseLine(line, source.options)
+		if errLine != nil {
+			source.Close()
+			return nil, errLine
+		}
+		if location.Accuracy >= source.options.minAccuracy {
+			source.offsets[location.ZipCode] = offset
+		}
+		offset = next
+	}
+	return source, nil
+}
+
+// lineAt returns the line starting at offset, without its line ending, and
+// the offset of the next line. The line is copied out of the mapped file so
+// the parsed locations stay valid after Close.
+func (s *MappedSource) lineAt(offset int) (string, int) {
+	end := bytes.IndexByte(s.data[offset:], '\n')
+	next := offset + end + 1
+	if end < 0 {
+		end = len(s.data) - offset
+		next = len(s.data)
+	}
+	return string(bytes.TrimSuffix(s.data[offset:offset+end], []byte("\r"))), next
+}
+
+// Get parses and returns the row of a zipcode
+func (s *MappedSource) Get(zipCode string) (ZipCodeLocation, bool) {
+	offset, found := s.offsets[zipCode]
+	if !found {
+		return ZipCodeLocation{}, false
+	}
+	line, _ := s.lineAt(offset)
+	location, err := parseLine(line, s.options)
+	return location, err == nil
+}
+
+// Range parses and calls fn for every zipcode of the file in file order,
+// until fn returns false
+func (s *MappedSource) Range(fn func(ZipCodeLocation) bool) {
+	for offset := 0; offset < len(s.data); {
+		line, next := s.lineAt(offset)
+		location, err := parseLine(line, s.options)
+		// skip the rows overridden by a later one or filtered out on open
+		if indexed, found := s.offsets[location.ZipCode]; err == nil && found && indexed == offset {
+			if !fn(location) {
+				return
+			}
+		}
+		offset = next
+	}
+}
+
+// Close unmaps the file. The source must not be used afterwards, locations
+// already returned stay valid.
+func (s *MappedSource) Close() error {
+	if s.unmap == nil {
+		return nil
+	}
+	err := s.unmap()
+	s.data, s.unmap = nil, nil
+	return err
+}
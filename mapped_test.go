@@ -0,0 +1,155 @@
+package zipcodes
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+func TestMappedSource(t *testing.T) {
+	source, err := OpenMappedSource("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error while mapping dataset %v", err)
+	}
+	defer source.Close()
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	mapped := NewFromSource(source)
+	for zipCode, expected := range zipcodesDataset.DatasetList {
+		location, err := mapped.Lookup(zipCode)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s %v", zipCode, err)
+		}
+		if *location != expected {
+			t.Errorf("Unexpected location for %s. Got %+v, want %+v", zipCode, *location, expected)
+		}
+	}
+	if _, err := mapped.Lookup("XYZ"); err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+
+	list, err := mapped.GetZipcodesWithinKmRadius("20457", 100)
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcodes within radius %v", err)
+	}
+	sort.Strings(list)
+	if reflect.DeepEqual(list, []string{"19053", "22525"}) != true {
+		t.Errorf("Unexpected zipcodes within radius. Got %v, want %v", list, []string{"19053", "22525"})
+	}
+	if mapped.IndexReady() {
+		t.Errorf("A radius query should not copy the mapped rows into a spatial index")
+	}
+
+	// The last row of a zipcode wins and filtered rows are not indexed
+	duplicates, err := OpenMappedSource("datasets/duplicates_dataset.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error while mapping dataset %v", err)
+	}
+	defer duplicates.Close()
+	location, _ := NewFromSource(duplicates).Lookup("10000")
+	if location.PlaceName != "Hanoi" {
+		t.Errorf("Unexpected location for a duplicated zipcode. Got %s, want %s", location.PlaceName, "Hanoi")
+	}
+	count := 0
+	duplicates.Range(func(ZipCodeLocation) bool {
+		count++
+		return true
+	})
+	if count != 1 {
+		t.Errorf("Unexpected amount of zipcodes. Got %d, want %d", count, 1)
+	}
+
+	accuracy, err := OpenMappedSource("datasets/accuracy_dataset.txt", WithMinAccuracy(5))
+	if err != nil {
+		t.Fatalf("Unexpected error while mapping dataset %v", err)
+	}
+	defer accuracy.Close()
+	if _, found := accuracy.Get("20000"); found {
+		t.Errorf("Zipcode 20000 should be filtered out by its accuracy")
+	}
+	if _, found := accuracy.Get("30000"); !found {
+		t.Errorf("Zipcode 30000 should be loaded")
+	}
+
+	// Failing cases
+	_, err = OpenMappedSource("datasets/wrong_length_dataset.txt")
+	if err == nil || err.Error() != "zipcodes: file line does not have 12 fields" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: file line does not have 12 fields")
+	}
+	_, err = OpenMappedSource("datasets/missing_dataset.txt")
+	if err == nil {
+		t.Errorf("Expected an error while mapping a missing file")
+	}
+}
+
+// benchmarkResidentHeap reports the heap retained by a dataset opened with
+// open once it has answered a radius query
+func benchmarkResidentHeap(b *testing.B, open func(path string) (*Zipcodes, error)) {
+	path := writeBenchmarkDataset(b, 50000)
+	var before, after runtime.MemStats
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		dataset, err := open(path)
+		if err != nil {
+			b.Fatalf("Unexpected error while opening dataset %v", err)
+		}
+		if _, err := dataset.GetZipcodesWithinKmRadius("01234", 50); err != nil {
+			b.Fatalf("Unexpected error while looking for zipcodes within radius %v", err)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.HeapAlloc)-float64(before.HeapAlloc), "heap-bytes")
+		runtime.KeepAlive(dataset)
+	}
+}
+
+func BenchmarkResidentHeapInMemory(b *testing.B) {
+	benchmarkResidentHeap(b, func(path string) (*Zipcodes, error) {
+		return New(path)
+	})
+}
+
+func BenchmarkResidentHeapMapped(b *testing.B) {
+	benchmarkResidentHeap(b, func(path string) (*Zipcodes, error) {
+		source, err := OpenMappedSource(path)
+		if err != nil {
+			return nil, err
+		}
+		b.Cleanup(func() { source.Close() })
+		return NewFromSource(source), nil
+	})
+}
+
+func BenchmarkResidentHeapMappedWarmUp(b *testing.B) {
+	benchmarkResidentHeap(b, func(path string) (*Zipcodes, error) {
+		source, err := OpenMappedSource(path)
+		if err != nil {
+			return nil, err
+		}
+		b.Cleanup(func() { source.Close() })
+		zipcodes := NewFromSource(source)
+		zipcodes.WarmUp()
+		return zipcodes, nil
+	})
+}
+
+func BenchmarkLookupMapped(b *testing.B) {
+	source, err := OpenMappedSource(writeBenchmarkDataset(b, 50000))
+	if err != nil {
+		b.Fatalf("Unexpected error while mapping dataset %v", err)
+	}
+	defer source.Close()
+	zipcodes := NewFromSource(source)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := zipcodes.Lookup("12345"); err != nil {
+			b.Fatalf("Unexpected error while looking for zipcode %v", err)
+		}
+	}
+}
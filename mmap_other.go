@@ -0,0 +1,17 @@
+//go:build !unix
+
+package zipcodes
+
+import (
+	"os"
+)
+
+// mapFile reads the whole file in memory on platforms without mmap support,
+// MappedSource then only saves the memory of the parsed locations
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
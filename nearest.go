@@ -0,0 +1,518 @@
+package zipcodes
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// ZipCodeWithDistance pairs a zipcode location with its distance in
+// Kilometers to a reference point
+type ZipCodeWithDistance struct {
+	ZipCodeLocation
+	Distance float64
+}
+
+// closer reports whether a zipcode at the given distance beats the current
+// best one. Equidistant zipcodes are ordered lexically, so nearest queries
+// return the same result whatever the iteration order of the dataset.
+func closer(distance float64, zipCode string, bestDistance float64, bestZipCode string) bool {
+	return distance < bestDistance || (distance == bestDistance && zipCode < bestZipCode)
+}
+
+// NearestPerCategory returns, for each category, the member zipcode closest
+// to the given zipcode together with its distance in Kilometers. Categories
+// without members are left out of the result. Ties go to the lowest zipcode.
+func (zc *Zipcodes) NearestPerCategory(from string, categories map[string][]string) (map[string]ZipCodeWithDistance, error) {
+	location, errLoc := zc.lookupWithCoordinates(from)
+	if errLoc != nil {
+		return nil, errLoc
+	}
+
+	nearest := make(map[string]ZipCodeWithDistance)
+	for category, zipCodes := range categories {
+		for _, zipCode := range zipCodes {
+			member, errMember := zc.lookupWithCoordinates(zipCode)
+			if errMember != nil {
+				return nil, errMember
+			}
+			distance := DistanceBetweenPoints(location.Lat, location.Lon, member.Lat, member.Lon, earthRadiusKm)
+			current, found := nearest[category]
+			if !found || closer(distance, zc.keyOf(*member), current.Distance, zc.keyOf(current.ZipCodeLocation)) {
+				nearest[category] = ZipCodeWithDistance{ZipCodeLocation: *member, Distance: distance}
+			}
+		}
+	}
+
+	return nearest, nil
+}
+
+// NearestWeighted returns the candidate with the smallest adjusted distance
+// to the given zipcode, each candidate being mapped to a multiplier applied to
+// its distance in Kilometers, e.g. to account for traffic or tolls. The
+// adjusted distance is returned with it. Ties go to the lowest zipcode.
+func (zc *Zipcodes) NearestWeighted(from string, candidates map[string]float64) (string, float64, error) {
+	if len(candidates) == 0 {
+		return "", 0, fmt.Errorf("zipcodes: candidate list is empty")
+	}
+	location, errLoc := zc.lookupWithCoordinates(from)
+	if errLoc != nil {
+		return "", 0, errLoc
+	}
+
+	nearest := ""
+	nearestDistance := math.Inf(1)
+	for zipCode, multiplier := range candidates {
+		if multiplier < 0 {
+			return "", 0, fmt.Errorf("zipcodes: multiplier for zipcode %s must not be negative", zipCode)
+		}
+		candidate, errCandidate := zc.lookupWithCoordinates(zipCode)
+		if errCandidate != nil {
+			return "", 0, errCandidate
+		}
+		distance := DistanceBetweenPoints(location.Lat, location.Lon, candidate.Lat, candidate.Lon, earthRadiusKm) * multiplier
+		distance = math.Round(distance*100) / 100
+		if closer(distance, zc.keyOf(*candidate), nearestDistance, nearest) {
+			nearest = zc.keyOf(*candidate)
+			nearestDistance = distance
+		}
+	}
+
+	return nearest, nearestDistance, nil
+}
+
+// AssignToNearestSeed maps every zipcode of the dataset to its closest seed,
+// the seeds being mapped to themselves. Zipcodes without coordinates are left
+// out and ties go to the lowest seed. Every zipcode is compared with every
+// seed, so the cost is O(dataset × seeds).
+func (zc *Zipcodes) AssignToNearestSeed(seeds []string) (map[string]string, error) {
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("zipcodes: seed list is empty")
+	}
+	sites, err := zc.locations(seeds)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := make(map[string]string)
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if !elm.HasCoordinates() {
+			return true
+		}
+		nearest := zc.keyOf(sites[0])
+		nearestDistance := math.Inf(1)
+		for _, site := range sites {
+			distance := DistanceBetweenPoints(elm.Lat, elm.Lon, site.Lat, site.Lon, earthRadiusKm)
+			if closer(distance, zc.keyOf(site), nearestDistance, nearest) {
+				nearest = zc.keyOf(site)
+				nearestDistance = distance
+			}
+		}
+		assignments[zc.keyOf(elm)] = nearest
+		return true
+	})
+	return assignments, nil
+}
+
+// sortByDistance sorts the list by increasing distance, ties going to the lowest zipcode
+func (zc *Zipcodes) sortByDistance(list []ZipCodeWithDistance) {
+	sort.Slice(list, func(i, j int) bool {
+		return closer(list[i].Distance, zc.keyOf(list[i].ZipCodeLocation), list[j].Distance, zc.keyOf(list[j].ZipCodeLocation))
+	})
+}
+
+// RankByDistance returns the candidates sorted by increasing distance in
+// Kilometers from the given zipcode, ties going to the lowest zipcode. An
+// unknown candidate, or one without coordinates, fails the whole ranking
+// instead of being silently left out.
+func (zc *Zipcodes) RankByDistance(from string, candidates []string) ([]ZipCodeWithDistance, error) {
+	location, errLoc := zc.lookupWithCoordinates(from)
+	if errLoc != nil {
+		return nil, errLoc
+	}
+	sites, err := zc.locations(candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]ZipCodeWithDistance, 0, len(sites))
+	for _, site := range sites {
+		distance := DistanceBetweenPoints(location.Lat, location.Lon, site.Lat, site.Lon, earthRadiusKm)
+		ranked = append(ranked, ZipCodeWithDistance{ZipCodeLocation: site, Distance: distance})
+	}
+	zc.sortByDistance(ranked)
+	return ranked, nil
+}
+
+// NearestIterator walks the zipcodes of a dataset by increasing distance to a point
+type NearestIterator struct {
+	results []ZipCodeWithDistance
+	next    int
+}
+
+// NearestIterator returns an iterator over the zipcodes with coordinates by
+// increasing distance in Kilometers to the given lat/lon. Distances are
+// computed and sorted once when the iterator is created, so each call to Next
+// is cheap but creating it costs a full scan of the dataset.
+func (zc *Zipcodes) NearestIterator(lat, lon float64) *NearestIterator {
+	results := []ZipCodeWithDistance{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			results = append(results, ZipCodeWithDistance{
+				ZipCodeLocation: elm,
+				Distance:        DistanceBetweenPoints(lat, lon, elm.Lat, elm.Lon, earthRadiusKm),
+			})
+		}
+		return true
+	})
+	zc.sortByDistance(results)
+	return &NearestIterator{results: results}
+}
+
+// Next returns the next closest zipcode, or false when every zipcode has been returned
+func (it *NearestIterator) Next() (ZipCodeWithDistance, bool) {
+	if it.next >= len(it.results) {
+		return ZipCodeWithDistance{}, false
+	}
+	it.next++
+	return it.results[it.next-1], true
+}
+
+// SpiralFrom calls fn for every other zipcode with coordinates by increasing
+// distance in Kilometers to the given zipcode, until fn returns false, e.g. to
+// render a map progressively from its center. Like NearestIterator, which it
+// is built on, the distances are sorted once before the first call to fn.
+func (zc *Zipcodes) SpiralFrom(zipCode string, fn func(ZipCodeWithDistance) bool) error {
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
+	if errLoc != nil {
+		return errLoc
+	}
+
+	key := zc.keyOf(*location)
+	it := zc.NearestIterator(location.Lat, location.Lon)
+	for {
+		elm, ok := it.Next()
+		if !ok {
+			return nil
+		}
+		if zc.keyOf(elm.ZipCodeLocation) != key && !fn(elm) {
+			return nil
+		}
+	}
+}
+
+// LookupNearest looks for a zipcode that may appear several times in the
+// dataset (e.g. the same code in different countries) and returns the row
+// closest to the given lat/lon. Rows without coordinates are only returned
+// when no other row has them.
+func (zc *Zipcodes) LookupNearest(zipCode string, nearLat, nearLon float64) (*ZipCodeLocation, error) {
+	rows := zc.records(zipCode)
+	if len(rows) == 0 {
+		return &ZipCodeLocation{}, fmt.Errorf("zipcodes: zipcode %s not found !", zipCode)
+	}
+
+	nearest := rows[0]
+	nearestDistance := math.Inf(1)
+	for _, row := range rows {
+		if !row.HasCoordinates() {
+			continue
+		}
+		distance := DistanceBetweenPoints(nearLat, nearLon, row.Lat, row.Lon, earthRadiusKm)
+		if distance < nearestDistance {
+			nearest = row
+			nearestDistance = distance
+		}
+	}
+	return &nearest, nil
+}
+
+// kNearest returns the k zipcodes closest to the location, itself excluded,
+// by increasing distance in Kilometers, ties going to the lowest zipcode. Like
+// NearestZipCode, the spatial index is searched within a growing radius, and
+// only the k best candidates are kept while searching.
+func (zc *Zipcodes) kNearest(location ZipCodeLocation, k int) []ZipCodeWithDistance {
+	return zc.kNearestWhere(location, k, nil)
+}
+
+// kNearestWhere is kNearest restricted to the zipcodes keep returns true
+// for, every zipcode when keep is nil
+func (zc *Zipcodes) kNearestWhere(location ZipCodeLocation, k int, keep func(ZipCodeLocation) bool) []ZipCodeWithDistance {
+	center := newPoint(location)
+	key := zc.keyOf(location)
+	radius := 25.0
+	if zc.spatialGrid() == nil {
+		// every search visits the whole dataset, do it only once
+		radius = math.Inf(1)
+	}
+	for ; ; radius *= 2 {
+		nearest := make([]ZipCodeWithDistance, 0, k)
+		zc.rangeNear(center, radius, earthRadiusKm, func(p point) bool {
+			if zc.keyOf(p.location) == key || (keep != nil && !keep(p.location)) {
+				return true
+			}
+			if distance := center.distanceTo(p, earthRadiusKm); distance < radius {
+				nearest = zc.insertNearest(nearest, k, ZipCodeWithDistance{ZipCodeLocation: p.location, Distance: distance})
+			}
+			return true
+		})
+		// every zipcode closer than radius was visited, so once k of them
+		// are found they are the k closest of the whole dataset
+		if len(nearest) == k || radius > math.Pi*earthRadiusKm {
+			return nearest
+		}
+	}
+}
+
+// insertNearest inserts the candidate in the list sorted by distance, keeping
+// at most k entries
+func (zc *Zipcodes) insertNearest(list []ZipCodeWithDistance, k int, candidate ZipCodeWithDistance) []ZipCodeWithDistance {
+	i := sort.Search(len(list), func(i int) bool {
+		return closer(candidate.Distance, zc.keyOf(candidate.ZipCodeLocation), list[i].Distance, zc.keyOf(list[i].ZipCodeLocation))
+	})
+	if i == k {
+		return list
+	}
+	if len(list) < k {
+		list = append(list, ZipCodeWithDistance{})
+	}
+	copy(list[i+1:], list[i:])
+	list[i] = candidate
+	return list
+}
+
+// AreAdjacent reports whether each zipcode is among the k nearest neighbors of
+// the other one. With k set to 1 it tells whether they are mutual nearest neighbors.
+func (zc *Zipcodes) AreAdjacent(a, b string, k int) (bool, error) {
+	if k < 1 {
+		return false, fmt.Errorf("zipcodes: k must be greater than zero")
+	}
+	if a == b {
+		return false, fmt.Errorf("zipcodes: zipcode %s can not be adjacent to itself", a)
+	}
+	locationA, errLocA := zc.lookupWithCoordinates(a)
+	if errLocA != nil {
+		return false, errLocA
+	}
+	locationB, errLocB := zc.lookupWithCoordinates(b)
+	if errLocB != nil {
+		return false, errLocB
+	}
+
+	return zc.containsZipCode(zc.kNearest(*locationA, k), b) && zc.containsZipCode(zc.kNearest(*locationB, k), a), nil
+}
+
+// NeighborSimilarity returns the Jaccard index of the areas of two zipcodes,
+// each area being the zipcode itself and the zipcodes within radiusKm of it.
+// It goes from 0 for disjoint areas to 1 for identical ones and helps finding
+// overlapping service territories.
+func (zc *Zipcodes) NeighborSimilarity(a, b string, radiusKm float64) (float64, error) {
+	locationA, errLocA := zc.lookupWithCoordinates(a)
+	if errLocA != nil {
+		return 0, errLocA
+	}
+	locationB, errLocB := zc.lookupWithCoordinates(b)
+	if errLocB != nil {
+		return 0, errLocB
+	}
+
+	areaA := map[string]bool{zc.keyOf(*locationA): true}
+	for _, zipCode := range zc.FindZipcodesWithinRadius(locationA, radiusKm, earthRadiusKm) {
+		areaA[zipCode] = true
+	}
+	shared, union := 0, len(areaA)
+	for _, zipCode := range append(zc.FindZipcodesWithinRadius(locationB, radiusKm, earthRadiusKm), zc.keyOf(*locationB)) {
+		if areaA[zipCode] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union), nil
+}
+
+// containsZipCode reports whether the zipcode, given by its key, is part of the list
+func (zc *Zipcodes) containsZipCode(list []ZipCodeWithDistance, zipCode string) bool {
+	for _, elm := range list {
+		if zc.keyOf(elm.ZipCodeLocation) == zipCode {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildAdjacencyGraph returns, for every zipcode with coordinates, the list of
+// its k nearest neighbors by increasing distance. The neighbors of each
+// zipcode are searched in the spatial index, so only the zipcodes around it
+// are compared. An empty graph is returned when k is lower than one.
+func (zc *Zipcodes) BuildAdjacencyGraph(k int) map[string][]string {
+	graph := make(map[string][]string)
+	if k < 1 {
+		return graph
+	}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if !elm.HasCoordinates() {
+			return true
+		}
+		neighbors := []string{}
+		for _, neighbor := range zc.kNearest(elm, k) {
+			neighbors = append(neighbors, zc.keyOf(neighbor.ZipCodeLocation))
+		}
+		graph[zc.keyOf(elm)] = neighbors
+		return true
+	})
+	return graph
+}
+
+// MostIsolatedZipCodes returns the n zipcodes whose nearest neighbor is the
+// farthest away, e.g. to spot remote areas, each with the distance in
+// Kilometers to that neighbor. The list is sorted by decreasing distance, ties
+// going to the lowest zipcode. Like BuildAdjacencyGraph, the neighbors are
+// searched in the spatial index.
+func (zc *Zipcodes) MostIsolatedZipCodes(n int) ([]ZipCodeWithDistance, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("zipcodes: n must be greater than zero")
+	}
+
+	isolated := []ZipCodeWithDistance{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if !elm.HasCoordinates() {
+			return true
+		}
+		if neighbor, found := zc.nearestNeighbor(elm); found {
+			isolated = append(isolated, ZipCodeWithDistance{ZipCodeLocation: elm, Distance: neighbor.Distance})
+		}
+		return true
+	})
+
+	sort.Slice(isolated, func(i, j int) bool {
+		return closer(-isolated[i].Distance, zc.keyOf(isolated[i].ZipCodeLocation), -isolated[j].Distance, zc.keyOf(isolated[j].ZipCodeLocation))
+	})
+	if len(isolated) > n {
+		isolated = isolated[:n]
+	}
+	return isolated, nil
+}
+
+// nearestNeighbor returns the zipcode closest to the location, itself
+// excluded, with the distance in Kilometers to it, and whether there is one
+func (zc *Zipcodes) nearestNeighbor(location ZipCodeLocation) (ZipCodeWithDistance, bool) {
+	neighbors := zc.kNearest(location, 1)
+	if len(neighbors) == 0 {
+		return ZipCodeWithDistance{}, false
+	}
+	return neighbors[0], true
+}
+
+// NearestZipCode returns the zipcode closest to the given lat/lon, e.g. to
+// reverse geocode a GPS position. The spatial index is searched within a
+// growing radius, so the cost depends on the distance to the nearest zipcode
+// rather than on the size of the dataset. Without a spatial index the dataset
+// is scanned once. Ties go to the lowest zipcode.
+func (zc *Zipcodes) NearestZipCode(lat, lon float64) (*ZipCodeLocation, error) {
+	center := newPoint(ZipCodeLocation{Lat: lat, Lon: lon})
+	radius := 25.0
+	if zc.spatialGrid() == nil {
+		// every search visits the whole dataset, do it only once
+		radius = math.Inf(1)
+	}
+	for ; ; radius *= 2 {
+		var nearest ZipCodeLocation
+		nearestDistance := math.Inf(1)
+		zc.rangeNear(center, radius, earthRadiusKm, func(p point) bool {
+			distance := center.distanceTo(p, earthRadiusKm)
+			if distance < radius && closer(distance, zc.keyOf(p.location), nearestDistance, zc.keyOf(nearest)) {
+				nearest = p.location
+				nearestDistance = distance
+			}
+			return true
+		})
+		// every zipcode closer than radius was visited, so the closest
+		// one found is the closest of the whole dataset
+		if !math.IsInf(nearestDistance, 1) {
+			return &nearest, nil
+		}
+		if radius > math.Pi*earthRadiusKm {
+			return &ZipCodeLocation{}, fmt.Errorf("zipcodes: dataset has no zipcode with coordinates")
+		}
+	}
+}
+
+// NearestZipCodesConcurrent returns the zipcode closest to each of the given
+// lat/lon points, in the order of the points, spreading them across workers
+// goroutines (GOMAXPROCS when workers is lower than one), e.g. to reverse
+// geocode thousands of GPS pings. A point gets nil when the dataset has no
+// zipcode with coordinates.
+func (zc *Zipcodes) NearestZipCodesConcurrent(points [][2]float64, workers int) []*ZipCodeLocation {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// build the spatial index once instead of in the first queries of every worker
+	zc.spatialGrid()
+
+	results := make([]*ZipCodeLocation, len(points))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if location, err := zc.NearestZipCode(points[i][0], points[i][1]); err == nil {
+					results[i] = location
+				}
+			}
+		}()
+	}
+	for i := range points {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// ExpandingRadiusSearch grows a radius around the given zipcode from startKm
+// by stepKm until it contains at least minCount zipcodes, and returns them
+// sorted by distance together with the radius in Kilometers that satisfied
+// the count. When even maxKm is not enough, every zipcode within maxKm is
+// returned with maxKm. As in the other radius queries the comparison is strict.
+func (zc *Zipcodes) ExpandingRadiusSearch(zipCode string, minCount int, startKm, stepKm, maxKm float64) ([]ZipCodeWithDistance, float64, error) {
+	if minCount <= 0 {
+		return nil, 0, fmt.Errorf("zipcodes: minCount must be greater than zero")
+	}
+	if startKm <= 0 || stepKm <= 0 || maxKm < startKm {
+		return nil, 0, fmt.Errorf("zipcodes: radii must be greater than zero and startKm must not exceed maxKm")
+	}
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
+	if errLoc != nil {
+		return nil, 0, errLoc
+	}
+
+	// a single query at maxKm is enough, the expansion is then replayed on
+	// the sorted distances
+	neighbors := []ZipCodeWithDistance{}
+	center := newPoint(*location)
+	key := zc.keyOf(*location)
+	zc.rangeNear(center, maxKm, earthRadiusKm, func(p point) bool {
+		if zc.keyOf(p.location) == key {
+			return true
+		}
+		if distance := center.distanceTo(p, earthRadiusKm); distance < maxKm {
+			neighbors = append(neighbors, ZipCodeWithDistance{ZipCodeLocation: p.location, Distance: distance})
+		}
+		return true
+	})
+	zc.sortByDistance(neighbors)
+
+	for step := 0; ; step++ {
+		radius := math.Min(startKm+float64(step)*stepKm, maxKm)
+		within := sort.Search(len(neighbors), func(i int) bool {
+			return neighbors[i].Distance >= radius
+		})
+		if within >= minCount || radius == maxKm {
+			return neighbors[:within], radius, nil
+		}
+	}
+}
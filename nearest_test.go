@@ -0,0 +1,700 @@
+package zipcodes
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNearestPerCategory(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	categories := map[string][]string{
+		"hospitals": {"20457", "03058", "87787"},
+		"schools":   {"19053", "22525"},
+		"empty":     {},
+	}
+	expected := map[string]ZipCodeWithDistance{
+		"hospitals": {ZipCodeLocation: zipcodesDataset.DatasetList["03058"], Distance: 49.87},
+		"schools":   {ZipCodeLocation: zipcodesDataset.DatasetList["19053"], Distance: 299.63},
+	}
+
+	nearest, err := zipcodesDataset.NearestPerCategory("01945", categories)
+	if err != nil {
+		t.Errorf("Unexpected error while looking for nearest zipcodes %v", err)
+	}
+	if len(nearest) != len(expected) {
+		t.Errorf("Unexpected amount of categories. Got %d, want %d", len(nearest), len(expected))
+	}
+	for category, want := range expected {
+		if nearest[category] != want {
+			t.Errorf("Unexpected nearest zipcode for %s. Got %+v, want %+v", category, nearest[category], want)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.NearestPerCategory("XYZ", categories)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+	_, err = zipcodesDataset.NearestPerCategory("01945", map[string][]string{"hospitals": {"11111"}})
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+}
+
+func TestNearestWeighted(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	cases := []struct {
+		Candidates       map[string]float64
+		ExpectedZipCode  string
+		ExpectedDistance float64
+	}{
+		{map[string]float64{"03058": 3, "19053": 0.4, "20457": 1}, "19053", 119.85},
+		{map[string]float64{"03058": 1, "19053": 1}, "03058", 49.87},
+		{map[string]float64{"03058": 1.2}, "03058", 59.84},
+		{map[string]float64{"19053": 0, "03058": 0}, "03058", 0},
+	}
+	for _, c := range cases {
+		zipCode, distance, err := zipcodesDataset.NearestWeighted("01945", c.Candidates)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for nearest zipcode %v", err)
+		}
+		if zipCode != c.ExpectedZipCode || distance != c.ExpectedDistance {
+			t.Errorf("Unexpected nearest zipcode for %v. Got %s %v, want %s %v", c.Candidates, zipCode, distance, c.ExpectedZipCode, c.ExpectedDistance)
+		}
+	}
+
+	// Failing cases
+	_, _, err = zipcodesDataset.NearestWeighted("01945", map[string]float64{})
+	if err == nil || err.Error() != "zipcodes: candidate list is empty" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: candidate list is empty")
+	}
+	_, _, err = zipcodesDataset.NearestWeighted("XYZ", map[string]float64{"03058": 1})
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+	_, _, err = zipcodesDataset.NearestWeighted("01945", map[string]float64{"11111": 1})
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+	_, _, err = zipcodesDataset.NearestWeighted("01945", map[string]float64{"03058": -1})
+	if err == nil || err.Error() != "zipcodes: multiplier for zipcode 03058 must not be negative" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: multiplier for zipcode 03058 must not be negative")
+	}
+}
+
+func TestRankByDistance(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	expected := []ZipCodeWithDistance{
+		{ZipCodeLocation: zipcodesDataset.DatasetList["22525"], Distance: 7.43},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["19053"], Distance: 94.8},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["34134"], Distance: 253.87},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["01945"], Distance: 357.59},
+	}
+	ranked, err := zipcodesDataset.RankByDistance("20457", []string{"34134", "22525", "19053", "01945"})
+	if err != nil {
+		t.Errorf("Unexpected error while ranking zipcodes %v", err)
+	}
+	if reflect.DeepEqual(ranked, expected) != true {
+		t.Errorf("RankByDistance returned an unexpected list. Got %+v, want %+v", ranked, expected)
+	}
+
+	ranked, err = zipcodesDataset.RankByDistance("20457", []string{})
+	if err != nil || len(ranked) != 0 {
+		t.Errorf("Unexpected result for an empty candidate list. Got %v, %v", ranked, err)
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.RankByDistance("XYZ", []string{"22525"})
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+	_, err = zipcodesDataset.RankByDistance("20457", []string{"22525", "11111"})
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+}
+
+func TestAssignToNearestSeed(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	assignments, err := zipcodesDataset.AssignToNearestSeed([]string{"01945", "20457", "87787"})
+	if err != nil {
+		t.Errorf("Unexpected error while assigning zipcodes %v", err)
+	}
+	expected := map[string]string{
+		"01945": "01945",
+		"03058": "01945",
+		"94051": "87787",
+		"87787": "87787",
+		"34134": "20457",
+		"20457": "20457",
+		"22525": "20457",
+		"19053": "20457",
+	}
+	if reflect.DeepEqual(assignments, expected) != true {
+		t.Errorf("Unexpected assignments. Got %v, want %v", assignments, expected)
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.AssignToNearestSeed([]string{})
+	if err == nil || err.Error() != "zipcodes: seed list is empty" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: seed list is empty")
+	}
+	_, err = zipcodesDataset.AssignToNearestSeed([]string{"01945", "11111"})
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+}
+
+func TestNearestTieBreaking(t *testing.T) {
+	zipcodesDataset, err := New("datasets/tie_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	// T1 and T2 are at the same distance of T0, the lowest zipcode wins
+	for _, seeds := range [][]string{{"T1", "T2"}, {"T2", "T1"}} {
+		assignments, err := zipcodesDataset.AssignToNearestSeed(seeds)
+		if err != nil {
+			t.Errorf("Unexpected error while assigning zipcodes %v", err)
+		}
+		if assignments["T0"] != "T1" {
+			t.Errorf("Unexpected seed for T0 with seeds %v. Got %s, want %s", seeds, assignments["T0"], "T1")
+		}
+
+		nearest, err := zipcodesDataset.NearestPerCategory("T0", map[string][]string{"seeds": seeds})
+		if err != nil {
+			t.Errorf("Unexpected error while looking for nearest zipcodes %v", err)
+		}
+		if nearest["seeds"].ZipCode != "T1" {
+			t.Errorf("Unexpected nearest zipcode with seeds %v. Got %s, want %s", seeds, nearest["seeds"].ZipCode, "T1")
+		}
+	}
+}
+
+func TestSpiralFrom(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	cases := []struct {
+		Limit    int
+		Expected []string
+	}{
+		{100, []string{"03058", "19053", "94051", "34134", "20457", "22525", "87787"}},
+		{3, []string{"03058", "19053", "94051"}},
+		{1, []string{"03058"}},
+	}
+	for _, c := range cases {
+		list := []string{}
+		previous := 0.0
+		err := zipcodesDataset.SpiralFrom("01945", func(elm ZipCodeWithDistance) bool {
+			if elm.Distance < previous {
+				t.Errorf("Distances are not increasing. Got %v after %v", elm.Distance, previous)
+			}
+			previous = elm.Distance
+			list = append(list, elm.ZipCode)
+			return len(list) < c.Limit
+		})
+		if err != nil {
+			t.Errorf("Unexpected error while walking zipcodes %v", err)
+		}
+		if reflect.DeepEqual(list, c.Expected) != true {
+			t.Errorf("Unexpected zipcode order. Got %v, want %v", list, c.Expected)
+		}
+	}
+
+	// Failing case
+	err = zipcodesDataset.SpiralFrom("XYZ", func(ZipCodeWithDistance) bool {
+		t.Errorf("Unexpected call for a missing zipcode")
+		return true
+	})
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestNearestIterator(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	it := zipcodesDataset.NearestIterator(51.4267, 13.9333)
+	expected := []string{"01945", "03058", "19053", "94051", "34134", "20457", "22525", "87787"}
+	list := []string{}
+	previous := 0.0
+	for {
+		elm, ok := it.Next()
+		if !ok {
+			break
+		}
+		if elm.Distance < previous {
+			t.Errorf("Distances are not increasing. Got %v after %v", elm.Distance, previous)
+		}
+		previous = elm.Distance
+		list = append(list, elm.ZipCode)
+	}
+	if reflect.DeepEqual(list, expected) != true {
+		t.Errorf("Unexpected zipcode order. Got %v, want %v", list, expected)
+	}
+
+	// The iterator stays exhausted
+	if _, ok := it.Next(); ok {
+		t.Errorf("Expected the iterator to be exhausted")
+	}
+}
+
+func TestLookupNearest(t *testing.T) {
+	cases := []struct {
+		ZipCode           string
+		NearLat           float64
+		NearLon           float64
+		ExpectedPlaceName string
+	}{
+		{"10000", 48.8566, 2.3522, "Troyes"},
+		{"10000", 46.0569, 14.5058, "Zagreb"},
+		{"10000", 16.0544, 108.2022, "Hanoi"},
+		{"20457", 0, 0, "Hamburg Neustadt"},
+	}
+	zipcodesDataset, err := NewFromPaths([]string{"datasets/duplicates_dataset.txt", "datasets/valid_dataset.txt"})
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		location, err := zipcodesDataset.LookupNearest(c.ZipCode, c.NearLat, c.NearLon)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s", err)
+		}
+		if location.PlaceName != c.ExpectedPlaceName {
+			t.Errorf("Unexpected place name near %v/%v. Got %s, want %s", c.NearLat, c.NearLon, location.PlaceName, c.ExpectedPlaceName)
+		}
+	}
+
+	// Lookup keeps returning the last loaded row
+	location, err := zipcodesDataset.Lookup("10000")
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if location.PlaceName != "Hanoi" {
+		t.Errorf("Unexpected place name. Got %s, want %s", location.PlaceName, "Hanoi")
+	}
+
+	_, err = zipcodesDataset.LookupNearest("XYZ", 0, 0)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+
+	// Identical rows without coordinates are not kept as duplicates
+	blankDataset, err := NewFromPaths([]string{"datasets/blank_coordinates_dataset.txt", "datasets/blank_coordinates_dataset.txt"}, WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if rows := blankDataset.records("96799"); len(rows) != 1 {
+		t.Errorf("Unexpected number of rows for zipcode 96799. Got %d, want %d", len(rows), 1)
+	}
+}
+
+func TestAreAdjacent(t *testing.T) {
+	cases := []struct {
+		A        string
+		B        string
+		K        int
+		Expected bool
+	}{
+		{"20457", "22525", 1, true},
+		{"01945", "03058", 1, true},
+		{"19053", "20457", 1, false},
+		{"19053", "20457", 2, true},
+		{"01945", "87787", 3, false},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		adjacent, err := zipcodesDataset.AreAdjacent(c.A, c.B, c.K)
+		if err != nil {
+			t.Errorf("Unexpected error while checking adjacency %v", err)
+		}
+		if adjacent != c.Expected {
+			t.Errorf("Unexpected adjacency of %s and %s with k=%d. Got %v, want %v", c.A, c.B, c.K, adjacent, c.Expected)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		A           string
+		B           string
+		K           int
+		ExpectedErr string
+	}{
+		{"20457", "22525", 0, "zipcodes: k must be greater than zero"},
+		{"20457", "20457", 1, "zipcodes: zipcode 20457 can not be adjacent to itself"},
+		{"20457", "11111", 1, "zipcodes: zipcode 11111 not found !"},
+	}
+	for _, c := range fail {
+		_, err := zipcodesDataset.AreAdjacent(c.A, c.B, c.K)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestBuildAdjacencyGraph(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	graph := zipcodesDataset.BuildAdjacencyGraph(1)
+	expected := map[string][]string{
+		"01945": {"03058"},
+		"03058": {"01945"},
+		"94051": {"87787"},
+		"87787": {"94051"},
+		"34134": {"20457"},
+		"20457": {"22525"},
+		"22525": {"20457"},
+		"19053": {"20457"},
+	}
+	if reflect.DeepEqual(graph, expected) != true {
+		t.Errorf("Unexpected adjacency graph. Got %v, want %v", graph, expected)
+	}
+
+	graph = zipcodesDataset.BuildAdjacencyGraph(2)
+	if reflect.DeepEqual(graph["19053"], []string{"20457", "22525"}) != true {
+		t.Errorf("Unexpected neighbors. Got %v, want %v", graph["19053"], []string{"20457", "22525"})
+	}
+
+	if len(zipcodesDataset.BuildAdjacencyGraph(0)) != 0 {
+		t.Errorf("Expected an empty graph for k=0")
+	}
+}
+
+// linearKNearest returns the k zipcodes closest to the location by comparing
+// it with every zipcode of the dataset, as a reference for kNearest
+func linearKNearest(zc *Zipcodes, location ZipCodeLocation, k int) []ZipCodeWithDistance {
+	center := newPoint(location)
+	neighbors := []ZipCodeWithDistance{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.ZipCode != location.ZipCode && elm.HasCoordinates() {
+			neighbors = append(neighbors, ZipCodeWithDistance{ZipCodeLocation: elm, Distance: center.distanceTo(newPoint(elm), earthRadiusKm)})
+		}
+		return true
+	})
+	zc.sortByDistance(neighbors)
+	if len(neighbors) > k {
+		neighbors = neighbors[:k]
+	}
+	return neighbors
+}
+
+func TestKNearestMatchesLinearScan(t *testing.T) {
+	datasets := []*Zipcodes{}
+	for _, path := range []string{"datasets/valid_dataset.txt", "datasets/antimeridian_dataset.txt", "datasets/tie_dataset.txt"} {
+		zipcodesDataset, err := New(path)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+			continue
+		}
+		datasets = append(datasets, zipcodesDataset)
+	}
+	// a regular grid, full of ties, read from a source with and without index
+	grid := sliceSource{}
+	for i := 0; i < 400; i++ {
+		grid = append(grid, ZipCodeLocation{ZipCode: fmt.Sprintf("%05d", i), Lat: 47 + float64(i%20)*0.05, Lon: 6 + float64(i/20)*0.05})
+	}
+	indexedGrid := NewFromSource(grid)
+	indexedGrid.WarmUp()
+	datasets = append(datasets, indexedGrid, NewFromSource(grid))
+
+	for _, zipcodesDataset := range datasets {
+		for _, k := range []int{1, 5, 50} {
+			zipcodesDataset.Range(func(location ZipCodeLocation) bool {
+				got := zipcodesDataset.kNearest(location, k)
+				want := linearKNearest(zipcodesDataset, location, k)
+				if reflect.DeepEqual(got, want) != true {
+					t.Errorf("kNearest of %s with k=%d returned %v, want %v", location.ZipCode, k, got, want)
+					return false
+				}
+				return true
+			})
+		}
+	}
+}
+
+func BenchmarkBuildAdjacencyGraph(b *testing.B) {
+	zipcodesDataset, err := New(writeBenchmarkDataset(b, 5000))
+	if err != nil {
+		b.Fatalf("Unexpected error while initializing struct %v", err)
+	}
+	zipcodesDataset.WarmUp()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zipcodesDataset.BuildAdjacencyGraph(5)
+	}
+}
+
+func BenchmarkMostIsolatedZipCodes(b *testing.B) {
+	zipcodesDataset, err := New(writeBenchmarkDataset(b, 5000))
+	if err != nil {
+		b.Fatalf("Unexpected error while initializing struct %v", err)
+	}
+	zipcodesDataset.WarmUp()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := zipcodesDataset.MostIsolatedZipCodes(10); err != nil {
+			b.Fatalf("Unexpected error while looking for isolated zipcodes %v", err)
+		}
+	}
+}
+
+func TestMostIsolatedZipCodes(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	expected := []ZipCodeWithDistance{
+		{ZipCodeLocation: zipcodesDataset.DatasetList["87787"], Distance: 262.43},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["94051"], Distance: 262.43},
+		{ZipCodeLocation: zipcodesDataset.DatasetList["34134"], Distance: 253.87},
+	}
+	isolated, err := zipcodesDataset.MostIsolatedZipCodes(3)
+	if err != nil {
+		t.Errorf("Unexpected error while looking for isolated zipcodes %v", err)
+	}
+	if reflect.DeepEqual(isolated, expected) != true {
+		t.Errorf("MostIsolatedZipCodes returned an unexpected list. Got %+v, want %+v", isolated, expected)
+	}
+
+	isolated, _ = zipcodesDataset.MostIsolatedZipCodes(100)
+	if len(isolated) != len(zipcodesDataset.DatasetList) {
+		t.Errorf("Unexpected amount of isolated zipcodes. Got %d, want %d", len(isolated), len(zipcodesDataset.DatasetList))
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.MostIsolatedZipCodes(0)
+	if err == nil || err.Error() != "zipcodes: n must be greater than zero" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: n must be greater than zero")
+	}
+}
+
+func TestNearestZipCode(t *testing.T) {
+	cases := []struct {
+		Dataset  string
+		Lat      float64
+		Lon      float64
+		Expected string
+	}{
+		{"datasets/valid_dataset.txt", 53.55, 9.98, "20457"},
+		{"datasets/valid_dataset.txt", 51.5, 14.2, "01945"},
+		{"datasets/valid_dataset.txt", -33.86, 151.2, "03058"},
+		{"datasets/tie_dataset.txt", 0, 0.5, "T0"},
+		{"datasets/antimeridian_dataset.txt", -16.8, -179.9, "WS01"},
+		{"datasets/antimeridian_dataset.txt", -16.5, 179.9, "FJ02"},
+		{"datasets/antimeridian_dataset.txt", 90, 0, "NO02"},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		location, err := zipcodesDataset.NearestZipCode(c.Lat, c.Lon)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for the nearest zipcode %v", err)
+		}
+		if location.ZipCode != c.Expected {
+			t.Errorf("Unexpected nearest zipcode to %v/%v. Got %s, want %s", c.Lat, c.Lon, location.ZipCode, c.Expected)
+		}
+	}
+
+	// Failing case
+	empty := NewFromSource(sliceSource{})
+	_, err := empty.NearestZipCode(0, 0)
+	if err == nil || err.Error() != "zipcodes: dataset has no zipcode with coordinates" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: dataset has no zipcode with coordinates")
+	}
+}
+
+// rangeCountingSource is a LocationSource counting the scans of the dataset
+type rangeCountingSource struct {
+	sliceSource
+	scans *int
+}
+
+func (s rangeCountingSource) Range(fn func(ZipCodeLocation) bool) {
+	*s.scans++
+	s.sliceSource.Range(fn)
+}
+
+func TestNearestZipCodeWithoutIndex(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	scans := 0
+	source := rangeCountingSource{scans: &scans}
+	for _, location := range zipcodesDataset.DatasetList {
+		source.sliceSource = append(source.sliceSource, location)
+	}
+	sourceDataset := NewFromSource(source)
+
+	// Sydney is thousands of Kilometers away from every zipcode, an unindexed
+	// dataset must still be scanned only once
+	scans = 0
+	location, err := sourceDataset.NearestZipCode(-33.86, 151.2)
+	if err != nil {
+		t.Errorf("Unexpected error while looking for the nearest zipcode %v", err)
+	}
+	if location.ZipCode != "03058" {
+		t.Errorf("Unexpected nearest zipcode. Got %s, want %s", location.ZipCode, "03058")
+	}
+	if scans != 1 {
+		t.Errorf("Unexpected number of dataset scans. Got %d, want %d", scans, 1)
+	}
+}
+
+func TestNearestZipCodesConcurrent(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	points := [][2]float64{}
+	for lat := 46.0; lat <= 56; lat += 0.5 {
+		for lon := 5.0; lon <= 16; lon += 0.5 {
+			points = append(points, [2]float64{lat, lon})
+		}
+	}
+	for _, workers := range []int{0, 1, 4, 16} {
+		results := zipcodesDataset.NearestZipCodesConcurrent(points, workers)
+		if len(results) != len(points) {
+			t.Fatalf("Unexpected amount of results. Got %d, want %d", len(results), len(points))
+		}
+		for i, p := range points {
+			expected, _ := zipcodesDataset.NearestZipCode(p[0], p[1])
+			if results[i] == nil || *results[i] != *expected {
+				t.Errorf("Unexpected nearest zipcode to %v with %d workers. Got %v, want %v", p, workers, results[i], expected)
+			}
+		}
+	}
+
+	empty := NewFromSource(sliceSource{})
+	results := empty.NearestZipCodesConcurrent([][2]float64{{0, 0}}, 2)
+	if len(results) != 1 || results[0] != nil {
+		t.Errorf("Unexpected results on an empty dataset. Got %v", results)
+	}
+}
+
+func TestExpandingRadiusSearch(t *testing.T) {
+	zipcodesDataset, err := New("datasets/chain_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	cases := []struct {
+		MinCount       int
+		StartKm        float64
+		StepKm         float64
+		MaxKm          float64
+		ExpectedList   []string
+		ExpectedRadius float64
+	}{
+		{1, 50, 50, 500, []string{"C2"}, 150},
+		{2, 50, 50, 500, []string{"C2", "C3"}, 250},
+		{3, 50, 50, 500, []string{"C2", "C3"}, 500},
+		{1, 50, 100, 120, []string{"C2"}, 120},
+		{1, 10, 10, 50, []string{}, 50},
+	}
+	for _, c := range cases {
+		neighbors, radius, err := zipcodesDataset.ExpandingRadiusSearch("C1", c.MinCount, c.StartKm, c.StepKm, c.MaxKm)
+		if err != nil {
+			t.Errorf("Unexpected error while expanding the radius %v", err)
+		}
+		list := []string{}
+		for _, elm := range neighbors {
+			list = append(list, elm.ZipCode)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true || radius != c.ExpectedRadius {
+			t.Errorf("ExpandingRadiusSearch returned an unexpected result for %+v. Got %v %v, want %v %v", c, list, radius, c.ExpectedList, c.ExpectedRadius)
+		}
+	}
+
+	neighbors, _, _ := zipcodesDataset.ExpandingRadiusSearch("C1", 2, 50, 50, 500)
+	if neighbors[0].Distance != 100.08 || neighbors[1].Distance != 200.15 {
+		t.Errorf("Unexpected distances. Got %v and %v, want 100.08 and 200.15", neighbors[0].Distance, neighbors[1].Distance)
+	}
+
+	// Failing cases
+	_, _, err = zipcodesDataset.ExpandingRadiusSearch("C1", 0, 50, 50, 500)
+	if err == nil || err.Error() != "zipcodes: minCount must be greater than zero" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: minCount must be greater than zero")
+	}
+	for _, radii := range [][3]float64{{0, 50, 500}, {50, 0, 500}, {50, 50, 10}} {
+		_, _, err = zipcodesDataset.ExpandingRadiusSearch("C1", 1, radii[0], radii[1], radii[2])
+		if err == nil || err.Error() != "zipcodes: radii must be greater than zero and startKm must not exceed maxKm" {
+			t.Errorf("Unexpected error for %v. Got %v, want %s", radii, err, "zipcodes: radii must be greater than zero and startKm must not exceed maxKm")
+		}
+	}
+	_, _, err = zipcodesDataset.ExpandingRadiusSearch("XYZ", 1, 50, 50, 500)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestNeighborSimilarity(t *testing.T) {
+	cases := []struct {
+		A, B     string
+		RadiusKm float64
+		Expected float64
+	}{
+		{"C1", "C2", 50, 0},
+		{"C1", "C2", 150, 2.0 / 3},
+		{"C1", "C3", 150, 1.0 / 3},
+		{"C1", "C3", 250, 1},
+		{"C1", "C4", 250, 0},
+		{"C1", "C1", 50, 1},
+	}
+	zipcodesDataset, err := New("datasets/chain_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		similarity, err := zipcodesDataset.NeighborSimilarity(c.A, c.B, c.RadiusKm)
+		if err != nil {
+			t.Errorf("Unexpected error while comparing %s and %s %v", c.A, c.B, err)
+		}
+		if similarity != c.Expected {
+			t.Errorf("Unexpected similarity between %s and %s within %v. Got %v, want %v", c.A, c.B, c.RadiusKm, similarity, c.Expected)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.NeighborSimilarity("XYZ", "C1", 100)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+	_, err = zipcodesDataset.NeighborSimilarity("C1", "XYZ", 100)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
@@ -0,0 +1,173 @@
+package zipcodes
+
+import (
+	"bufio"
+	"io"
+)
+
+// defaultMaxLineLength is the longest dataset line accepted when the
+// WithMaxLineLength option is not given, 1MB
+const defaultMaxLineLength = 1024 * 1024
+
+// loadOptions holds the settings used while parsing a dataset
+type loadOptions struct {
+	allowBlankCoordinates bool
+	buildIndex            bool
+	internStrings         bool
+	interned              map[string]string
+	minAccuracy           int
+	placeNameIndex        bool
+	modifiedAtColumn      int
+	maxRadiusResults      int
+	maxLineLength         int
+	keyFunc               func(ZipCodeLocation) string
+}
+
+// Option configures how a dataset is loaded
+type Option func(*loadOptions)
+
+// WithBlankCoordinates accepts rows whose latitude or longitude is empty
+// instead of failing the whole load. Such rows can still be looked up, their
+// Lat and Lon are set to NaN and they are skipped by distance computations.
+func WithBlankCoordinates() Option {
+	return func(o *loadOptions) {
+		o.allowBlankCoordinates = true
+	}
+}
+
+// WithSpatialIndex builds the spatial index used by the radius queries while
+// loading the dataset instead of on the first query
+func WithSpatialIndex() Option {
+	return func(o *loadOptions) {
+		o.buildIndex = true
+	}
+}
+
+// WithStringInterning makes the entries share the memory of repeated values
+// (place names, admin names, state codes) instead of keeping one copy per
+// row, which noticeably reduces the heap used by large datasets
+func WithStringInterning() Option {
+	return func(o *loadOptions) {
+		o.internStrings = true
+	}
+}
+
+// WithMinAccuracy skips the rows whose accuracy is lower than level, a blank
+// accuracy counting as 0. GeoNames uses 1 for estimated coordinates, 4 for
+// geonameid based ones and 6 for centroids of addresses or streets.
+func WithMinAccuracy(level int) Option {
+	return func(o *loadOptions) {
+		o.minAccuracy = level
+	}
+}
+
+// WithPlaceNameIndex indexes the place names folded to lowercase ASCII while
+// loading, so LookupByPlaceName finds "Düsseldorf" from "dusseldorf" without
+// scanning the whole dataset
+func WithPlaceNameIndex() Option {
+	return func(o *loadOptions) {
+		o.placeNameIndex = true
+	}
+}
+
+// WithModifiedAtColumn reads the modification date (YYYY-MM-DD) of the rows
+// from the given zero-based column, for extended exports appending it after
+// the 12 standard columns. Rows without that column, or with it blank, keep a
+// zero ModifiedAt.
+func WithModifiedAtColumn(index int) Option {
+	return func(o *loadOptions) {
+		o.modifiedAtColumn = index
+	}
+}
+
+// WithMaxRadiusResults caps the number of zipcodes collected by the radius
+// queries (GetZipcodesWithinKmRadius, GetZipcodesWithinMlRadius,
+// ZipcodesWithinKmRadiusWhere, ZipcodesWithinTravelTime,
+// ZipcodesWithinKmRadiusGroupedBy and ZipcodesWithinKmRadiusGeoJSON),
+// protecting servers from huge result sets.
+// Once the cap is hit the query stops and returns the zipcodes collected so
+// far together with ErrTooManyResults. The cap applies before sorting: the
+// kept zipcodes are the first ones found, not the lowest or the closest ones.
+func WithMaxRadiusResults(n int) Option {
+	return func(o *loadOptions) {
+		o.maxRadiusResults = n
+	}
+}
+
+// WithMaxLineLength changes the length in bytes of the longest dataset line
+// that can be read, 1MB by default. Loading a dataset with a longer line, e.g.
+// with many extra columns, fails with an error naming the limit.
+func WithMaxLineLength(bytes int) Option {
+	return func(o *loadOptions) {
+		o.maxLineLength = bytes
+	}
+}
+
+// WithKeyFunc derives the key under which every row is stored from the row
+// itself instead of using the bare zipcode, e.g. the country code and the
+// zipcode together so datasets covering several countries do not collide.
+// Lookup and the other methods taking a zipcode then expect that key, and the
+// methods returning zipcodes, e.g. the radius queries or DBSCAN, return it
+// too, while the ZipCode field of the returned locations keeps the bare
+// zipcode. Results sorted or tied "by zipcode" are ordered by key as well.
+// OpenMappedSource ignores it and keys its rows by zipcode.
+func WithKeyFunc(fn func(ZipCodeLocation) string) Option {
+	return func(o *loadOptions) {
+		o.keyFunc = fn
+	}
+}
+
+// newLoadOptions applies the given options over the defaults
+func newLoadOptions(opts []Option) loadOptions {
+	options := loadOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.internStrings {
+		options.interned = make(map[string]string)
+	}
+	return options
+}
+
+// intern returns the shared copy of s when string interning is enabled
+func (o loadOptions) intern(s string) string {
+	if o.interned == nil {
+		return s
+	}
+	if shared, found := o.interned[s]; found {
+		return shared
+	}
+	// s points into the whole dataset line, keep a copy of its own instead
+	shared := string([]byte(s))
+	o.interned[shared] = shared
+	return shared
+}
+
+// detach returns a copy of s that does not retain the dataset line it was
+// read from, when string interning is enabled
+func (o loadOptions) detach(s string) string {
+	if o.interned == nil {
+		return s
+	}
+	return string([]byte(s))
+}
+
+// lineLengthLimit returns the length in bytes of the longest line accepted
+func (o loadOptions) lineLengthLimit() int {
+	if o.maxLineLength <= 0 {
+		return defaultMaxLineLength
+	}
+	return o.maxLineLength
+}
+
+// newScanner returns a line scanner over r accepting lines up to the line
+// length limit of the options
+func (o loadOptions) newScanner(r io.Reader) *bufio.Scanner {
+	initialSize := bufio.MaxScanTokenSize
+	if o.lineLengthLimit() < initialSize {
+		initialSize = o.lineLengthLimit()
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, initialSize), o.lineLengthLimit())
+	return scanner
+}
@@ -0,0 +1,382 @@
+package zipcodes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithStringInterning(t *testing.T) {
+	plain, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	interned, err := New("datasets/valid_dataset.txt", WithStringInterning())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if reflect.DeepEqual(plain.DatasetList, interned.DatasetList) != true {
+		t.Errorf("Interned dataset differs from the plain one")
+	}
+}
+
+func TestWithMinAccuracy(t *testing.T) {
+	cases := []struct {
+		Level        int
+		ExpectedList []string
+	}{
+		{0, []string{"10000", "20000", "30000", "40000"}},
+		{4, []string{"20000", "30000"}},
+		{5, []string{"30000"}},
+		{7, []string{}},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New("datasets/accuracy_dataset.txt", WithMinAccuracy(c.Level))
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		list := []string{}
+		for zip := range zipcodesDataset.DatasetList {
+			list = append(list, zip)
+		}
+		sort.Strings(list)
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("Unexpected zipcodes loaded with accuracy %d. Got %v, want %v", c.Level, list, c.ExpectedList)
+		}
+	}
+
+	// Failing case
+	_, err := LoadDataset("datasets/wrong_accuracy_dataset.txt")
+	if err == nil || err.Error() != "zipcodes: error while converting WRONG to Accuracy" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: error while converting WRONG to Accuracy")
+	}
+}
+
+func TestWithModifiedAtColumn(t *testing.T) {
+	zipcodesDataset, err := New("datasets/modified_at_dataset.txt", WithModifiedAtColumn(12))
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	expected := map[string]time.Time{
+		"01945": time.Date(2024, time.March, 18, 0, 0, 0, 0, time.UTC),
+		"03058": {},
+		"20457": {},
+	}
+	for zipCode, want := range expected {
+		location, err := zipcodesDataset.Lookup(zipCode)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s %v", zipCode, err)
+		}
+		if !location.ModifiedAt.Equal(want) {
+			t.Errorf("Unexpected modification date for %s. Got %v, want %v", zipCode, location.ModifiedAt, want)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		Dataset     string
+		Options     []Option
+		ExpectedErr string
+	}{
+		{"datasets/modified_at_dataset.txt", []Option{}, "zipcodes: file line does not have 12 fields"},
+		{"datasets/modified_at_dataset.txt", []Option{WithModifiedAtColumn(13)}, "zipcodes: file line does not have 12 or 14 fields"},
+		{"datasets/modified_at_dataset.txt", []Option{WithModifiedAtColumn(11)}, "zipcodes: modification date column 11 overlaps the standard columns"},
+		{"datasets/wrong_modified_at_dataset.txt", []Option{WithModifiedAtColumn(12)}, "zipcodes: error while converting 18/03/2024 to ModifiedAt"},
+	}
+	for _, c := range fail {
+		_, err := LoadDataset(c.Dataset, c.Options...)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestWithMaxRadiusResults(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt", WithMaxRadiusResults(1))
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	// 19053 and 22525 are within 100 Km of 20457
+	list, err := zipcodesDataset.GetZipcodesWithinKmRadius("20457", 100)
+	if err != ErrTooManyResults || len(list) != 1 {
+		t.Errorf("Unexpected truncated result. Got %v/%v, want 1 zipcode/%v", list, err, ErrTooManyResults)
+	}
+	list, err = zipcodesDataset.GetZipcodesWithinMlRadius("20457", 62)
+	if err != ErrTooManyResults || len(list) != 1 {
+		t.Errorf("Unexpected truncated result. Got %v/%v, want 1 zipcode/%v", list, err, ErrTooManyResults)
+	}
+	locations, err := zipcodesDataset.ZipcodesWithinKmRadiusWhere("20457", 100, nil)
+	if err != ErrTooManyResults || len(locations) != 1 {
+		t.Errorf("Unexpected truncated result. Got %v/%v, want 1 zipcode/%v", locations, err, ErrTooManyResults)
+	}
+	locations, err = zipcodesDataset.ZipcodesWithinTravelTime("20457", 60, 100)
+	if err != ErrTooManyResults || len(locations) != 1 {
+		t.Errorf("Unexpected truncated result. Got %v/%v, want 1 zipcode/%v", locations, err, ErrTooManyResults)
+	}
+	if _, err = zipcodesDataset.ZipcodesWithinKmRadiusGeoJSON("20457", 100); err != ErrTooManyResults {
+		t.Errorf("Unexpected error. Got %v, want %v", err, ErrTooManyResults)
+	}
+
+	// Queries matching no more zipcodes than the cap are not truncated
+	list, err = zipcodesDataset.GetZipcodesWithinKmRadius("20457", 10)
+	if err != nil || reflect.DeepEqual(list, []string{"22525"}) != true {
+		t.Errorf("Unexpected result. Got %v/%v, want %v/nil", list, err, []string{"22525"})
+	}
+	locations, err = zipcodesDataset.ZipcodesWithinKmRadiusWhere("20457", 100, func(elm ZipCodeLocation) bool {
+		return elm.StateCode == "MV"
+	})
+	if err != nil || len(locations) != 1 || locations[0].ZipCode != "19053" {
+		t.Errorf("Unexpected result. Got %v/%v, want 19053/nil", locations, err)
+	}
+
+	// FindZipcodesWithinRadius is not capped
+	location, _ := zipcodesDataset.Lookup("20457")
+	if list := zipcodesDataset.FindZipcodesWithinRadius(location, 100, earthRadiusKm); len(list) != 2 {
+		t.Errorf("Unexpected amount of zipcodes. Got %d, want %d", len(list), 2)
+	}
+}
+
+// writeBenchmarkDataset writes a dataset of n rows spread over a few states
+// and places and returns its path
+func writeBenchmarkDataset(b *testing.B, n int) string {
+	path := filepath.Join(b.TempDir(), "benchmark_dataset.txt")
+	file, err := os.Create(path)
+	if err != nil {
+		b.Fatalf("Unexpected error while creating dataset %v", err)
+	}
+	defer file.Close()
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(file, "DE\t%05d\tPlace %d\tState %d\tS%d\t\t00\tCounty %d\t00000\t%v\t%v\t4\n",
+			i, i%500, i%16, i%16, i%400, 47+float64(i%600)/100, 6+float64(i%900)/100)
+	}
+	return path
+}
+
+// benchmarkLoadHeap loads the dataset b.N times and reports the heap retained by it
+func benchmarkLoadHeap(b *testing.B, opts ...Option) {
+	path := writeBenchmarkDataset(b, 50000)
+	var before, after runtime.MemStats
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		zipcodes, err := New(path, opts...)
+		if err != nil {
+			b.Fatalf("Unexpected error while initializing struct %v", err)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.HeapAlloc)-float64(before.HeapAlloc), "heap-bytes")
+		runtime.KeepAlive(zipcodes)
+	}
+}
+
+func BenchmarkLoadDataset(b *testing.B) {
+	benchmarkLoadHeap(b)
+}
+
+func BenchmarkLoadDatasetWithStringInterning(b *testing.B) {
+	benchmarkLoadHeap(b, WithStringInterning())
+}
+
+func TestWithMaxLineLength(t *testing.T) {
+	// a line longer than the 64KB bufio.Scanner limit
+	longPlaceName := strings.Repeat("a", 100000)
+	path := filepath.Join(t.TempDir(), "long_line_dataset.txt")
+	line := "DE\t01945\t" + longPlaceName + "\tBrandenburg\tBB\t\t00\tLandkreis Oberspreewald-Lausitz\t12066\t51.4167\t13.9333\t4\n"
+	if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+		t.Fatalf("Unexpected error while writing dataset %v", err)
+	}
+
+	for _, opts := range [][]Option{{}, {WithMaxLineLength(200000)}} {
+		zipcodesDataset, err := New(path, opts...)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+			continue
+		}
+		if zipcodesDataset.DatasetList["01945"].PlaceName != longPlaceName {
+			t.Errorf("Unexpected place name of %d bytes, want %d", len(zipcodesDataset.DatasetList["01945"].PlaceName), len(longPlaceName))
+		}
+	}
+
+	// Failing cases
+	_, err := New(path, WithMaxLineLength(1000))
+	if err == nil || err.Error() != "zipcodes: dataset line longer than 1000 bytes, see WithMaxLineLength" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: dataset line longer than 1000 bytes, see WithMaxLineLength")
+	}
+	_, err = InspectDataset(strings.NewReader(strings.Repeat("a", 2*1024*1024)))
+	if err == nil || err.Error() != "zipcodes: dataset line longer than 1048576 bytes" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: dataset line longer than 1048576 bytes")
+	}
+}
+
+func TestWithKeyFunc(t *testing.T) {
+	countryKey := WithKeyFunc(func(location ZipCodeLocation) string {
+		return location.CountryCode + "-" + location.ZipCode
+	})
+
+	defaultDataset, err := New("datasets/multi_country_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if len(defaultDataset.DatasetList) != 2 || defaultDataset.DatasetList["01000"].CountryCode != "MX" {
+		t.Errorf("Unexpected default keys. Got %v", defaultDataset.DatasetList)
+	}
+
+	zipcodesDataset, err := New("datasets/multi_country_dataset.txt", countryKey, WithPlaceNameIndex())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	cases := []struct {
+		Key               string
+		ExpectedZipCode   string
+		ExpectedPlaceName string
+	}{
+		{"FR-01000", "01000", "Bourg-en-Bresse"},
+		{"MX-01000", "01000", "San Ángel"},
+		{"DE-80331", "80331", "München"},
+	}
+	for _, c := range cases {
+		location, err := zipcodesDataset.Lookup(c.Key)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for %s %v", c.Key, err)
+			continue
+		}
+		if location.ZipCode != c.ExpectedZipCode || location.PlaceName != c.ExpectedPlaceName {
+			t.Errorf("Unexpected location for %s. Got %s %s, want %s %s", c.Key, location.ZipCode, location.PlaceName, c.ExpectedZipCode, c.ExpectedPlaceName)
+		}
+	}
+
+	if _, err := zipcodesDataset.DistanceInKm("FR-01000", "MX-01000"); err != nil {
+		t.Errorf("Unexpected error while computing distance %v", err)
+	}
+	locations, err := zipcodesDataset.LookupByPlaceName("munchen")
+	if err != nil || len(locations) != 1 || locations[0].CountryCode != "DE" {
+		t.Errorf("Unexpected place name lookup. Got %v %v", locations, err)
+	}
+	list := []string{}
+	for _, elm := range zipcodesDataset.ZipCodesInRange("FR-", "MX-~") {
+		list = append(list, elm.CountryCode)
+	}
+	if reflect.DeepEqual(list, []string{"FR", "MX"}) != true {
+		t.Errorf("ZipCodesInRange returned an unexpected list. Got %v, want %v", list, []string{"FR", "MX"})
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.Lookup("01000")
+	if err == nil || err.Error() != "zipcodes: zipcode 01000 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 01000 not found !")
+	}
+}
+
+func TestWithKeyFuncTies(t *testing.T) {
+	countryKey := WithKeyFunc(func(location ZipCodeLocation) string {
+		return location.CountryCode + "-" + location.ZipCode
+	})
+	// the two rows share the zipcode and the point, ties go to the lowest
+	// key whatever the map iteration order
+	for i := 0; i < 20; i++ {
+		zipcodesDataset, err := New("datasets/keyed_tie_dataset.txt", countryKey)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		location, err := zipcodesDataset.NearestZipCode(47.2, 9.5)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for the nearest zipcode %v", err)
+		}
+		if location.CountryCode != "AT" {
+			t.Errorf("Unexpected nearest zipcode. Got %s-%s, want AT-9999", location.CountryCode, location.ZipCode)
+		}
+		north, south, east, west := zipcodesDataset.Extremes()
+		for _, extreme := range []ZipCodeLocation{north, south, east, west} {
+			if extreme.CountryCode != "AT" {
+				t.Errorf("Unexpected extreme zipcode. Got %s-%s, want AT-9999", extreme.CountryCode, extreme.ZipCode)
+			}
+		}
+	}
+}
+
+func TestWithKeyFuncQueries(t *testing.T) {
+	countryKey := WithKeyFunc(func(location ZipCodeLocation) string {
+		return location.CountryCode + "-" + location.ZipCode
+	})
+	zipcodesDataset, err := New("datasets/border_dataset.txt", countryKey)
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	movedDataset, err := New("datasets/border_moved_dataset.txt", countryKey)
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	radiusCases := []struct {
+		Name     string
+		Query    func() ([]string, error)
+		Expected []string
+	}{
+		{"GetZipcodesWithinKmRadius", func() ([]string, error) {
+			return zipcodesDataset.GetZipcodesWithinKmRadius("AT-6900", 200)
+		}, []string{"CH-6900", "CH-9400"}},
+		{"GetZipcodesWithinMlRadius", func() ([]string, error) {
+			return zipcodesDataset.GetZipcodesWithinMlRadius("AT-6900", 200)
+		}, []string{"CH-6900", "CH-9400"}},
+		{"FindZipcodesWithinRadius", func() ([]string, error) {
+			location, err := zipcodesDataset.Lookup("CH-6900")
+			return zipcodesDataset.FindZipcodesWithinRadius(location, 200, earthRadiusKm), err
+		}, []string{"AT-6900", "CH-9400"}},
+	}
+	for _, c := range radiusCases {
+		list, err := c.Query()
+		if err != nil {
+			t.Errorf("Unexpected error in %s %v", c.Name, err)
+		}
+		sort.Strings(list)
+		if reflect.DeepEqual(list, c.Expected) != true {
+			t.Errorf("%s returned an unexpected list. Got %v, want %v", c.Name, list, c.Expected)
+		}
+	}
+	locations, err := zipcodesDataset.ZipcodesWithinKmRadiusWhere("AT-6900", 200, nil)
+	if err != nil || len(locations) != 2 {
+		t.Errorf("ZipcodesWithinKmRadiusWhere returned an unexpected list. Got %v %v", locations, err)
+	}
+
+	clusters, noise, err := zipcodesDataset.DBSCAN(200, 2)
+	if err != nil {
+		t.Errorf("Unexpected error in DBSCAN %v", err)
+	}
+	expectedClusters := [][]string{{"AT-6900", "CH-6900", "CH-9400"}}
+	if reflect.DeepEqual(clusters, expectedClusters) != true || reflect.DeepEqual(noise, []string{"AT-9400"}) != true {
+		t.Errorf("DBSCAN returned unexpected clusters. Got %v %v, want %v %v", clusters, noise, expectedClusters, []string{"AT-9400"})
+	}
+
+	moves, err := MovedZipcodes(zipcodesDataset, movedDataset, 1)
+	if err != nil {
+		t.Errorf("Unexpected error in MovedZipcodes %v", err)
+	}
+	expectedMoves := []ZipMove{{ZipCode: "CH-6900", OldLat: 46.0037, OldLon: 8.9511, NewLat: 46.1037, NewLon: 8.9511, Distance: 11.12}}
+	if reflect.DeepEqual(moves, expectedMoves) != true {
+		t.Errorf("MovedZipcodes returned unexpected moves. Got %v, want %v", moves, expectedMoves)
+	}
+
+	expectedGraph := map[string][]string{
+		"AT-6900": {"CH-9400"},
+		"AT-9400": {"AT-6900"},
+		"CH-6900": {"CH-9400"},
+		"CH-9400": {"AT-6900"},
+	}
+	if graph := zipcodesDataset.BuildAdjacencyGraph(1); reflect.DeepEqual(graph, expectedGraph) != true {
+		t.Errorf("BuildAdjacencyGraph returned an unexpected graph. Got %v, want %v", graph, expectedGraph)
+	}
+	assignments, err := zipcodesDataset.AssignToNearestSeed([]string{"AT-6900", "AT-9400"})
+	expectedAssignments := map[string]string{"AT-6900": "AT-6900", "AT-9400": "AT-9400", "CH-6900": "AT-6900", "CH-9400": "AT-6900"}
+	if err != nil || reflect.DeepEqual(assignments, expectedAssignments) != true {
+		t.Errorf("AssignToNearestSeed returned unexpected assignments. Got %v %v, want %v", assignments, err, expectedAssignments)
+	}
+}
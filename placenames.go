@@ -0,0 +1,146 @@
+package zipcodes
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// asciiFolds maps the accented latin letters to their plain ASCII spelling,
+// lowercase only since names are lowered first. It is generated from the
+// Unicode canonical decompositions: every lowercase letter of the Latin-1
+// Supplement, Latin Extended-A/B and Latin Extended Additional blocks that
+// decomposes to a base letter plus combining marks, e.g. "ạ", "ồ" or "ǎ". The
+// letters without a decomposition (ø, đ, ł, æ, ß, ...) are listed by hand
+// after them. Other letters are kept as is.
+var asciiFolds = buildASCIIFolds(map[string]string{
+	"a":  "àáâãäåāăąǎǟǡǻȁȃȧḁạảấầẩẫậắằẳẵặ",
+	"b":  "ḃḅḇ",
+	"c":  "çćĉċčḉ",
+	"d":  "ďḋḍḏḑḓđð",
+	"e":  "èéêëēĕėęěȅȇȩḕḗḙḛḝẹẻẽếềểễệ",
+	"f":  "ḟ",
+	"g":  "ĝğġģǧǵḡ",
+	"h":  "ĥȟḣḥḧḩḫẖħ",
+	"i":  "ìíîïĩīĭįǐȉȋḭḯỉịı",
+	"j":  "ĵǰ",
+	"k":  "ķǩḱḳḵ",
+	"l":  "ĺļľḷḹḻḽŀł",
+	"m":  "ḿṁṃ",
+	"n":  "ñńņňǹṅṇṉṋŉ",
+	"o":  "òóôõöōŏőơǒǫǭǿȍȏȫȭȯȱṍṏṑṓọỏốồổỗộớờởỡợø",
+	"p":  "ṕṗ",
+	"r":  "ŕŗřȑȓṙṛṝṟ",
+	"s":  "śŝşšșṡṣṥṧṩ",
+	"t":  "ţťțṫṭṯṱẗŧ",
+	"u":  "ùúûüũūŭůűųưǔǖǘǚǜȕȗṳṵṷṹṻụủứừửữự",
+	"v":  "ṽṿ",
+	"w":  "ŵẁẃẅẇẉẘ",
+	"x":  "ẋẍ",
+	"y":  "ýÿŷȳẏẙỳỵỷỹ",
+	"z":  "źżžẑẓẕ",
+	"ae": "ǣǽæ",
+	"oe": "œ",
+	"ss": "ß",
+	"th": "þ",
+})
+
+// buildASCIIFolds turns a map of replacement -> accented letters into a
+// lookup table by accented letter
+func buildASCIIFolds(groups map[string]string) map[rune]string {
+	folds := make(map[rune]string)
+	for ascii, letters := range groups {
+		for _, r := range letters {
+			folds[r] = ascii
+		}
+	}
+	return folds
+}
+
+// foldPlaceName lowercases the name and strips its diacritics, so "Düsseldorf"
+// and "dusseldorf" fold to the same key. The standard library has no Unicode
+// decomposition, so precomposed letters go through asciiFolds while already
+// decomposed combining marks are dropped.
+func foldPlaceName(name string) string {
+	var folded strings.Builder
+	for _, r := range strings.TrimSpace(name) {
+		r = unicode.ToLower(r)
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if ascii, found := asciiFolds[r]; found {
+			folded.WriteString(ascii)
+			continue
+		}
+		folded.WriteRune(r)
+	}
+	return folded.String()
+}
+
+// buildPlaceNameIndex maps every folded place name of the dataset to its zipcodes
+func (zc *Zipcodes) buildPlaceNameIndex() {
+	index := make(map[string][]string)
+	zc.Range(func(elm ZipCodeLocation) bool {
+		key := foldPlaceName(elm.PlaceName)
+		index[key] = append(index[key], zc.keyOf(elm))
+		return true
+	})
+	zc.placeNames = index
+}
+
+// LookupByPlaceName returns the zipcodes whose place name matches the given
+// one regardless of case and accents, sorted by zipcode. The returned entries
+// keep their original place name. Datasets loaded with WithPlaceNameIndex
+// answer from the index, others fold every place name on each call.
+func (zc *Zipcodes) LookupByPlaceName(name string) ([]ZipCodeLocation, error) {
+	key := foldPlaceName(name)
+	locations := []ZipCodeLocation{}
+	if zc.placeNames != nil {
+		for _, zipCode := range zc.placeNames[key] {
+			if location, found := zc.Get(zipCode); found {
+				locations = append(locations, location)
+			}
+		}
+	} else {
+		zc.Range(func(elm ZipCodeLocation) bool {
+			if foldPlaceName(elm.PlaceName) == key {
+				locations = append(locations, elm)
+			}
+			return true
+		})
+	}
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("zipcodes: place name %s not found !", name)
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		return zc.keyOf(locations[i]) < zc.keyOf(locations[j])
+	})
+	return locations, nil
+}
+
+// LookupByPlaceNameRegex returns the zipcodes whose place name matches the
+// regular expression, sorted by zipcode, e.g. "burg$" for every place ending in
+// "burg". Unlike LookupByPlaceName the match is done on the original place
+// name, use (?i) to ignore case. The list is empty when nothing matches.
+func (zc *Zipcodes) LookupByPlaceNameRegex(pattern string) ([]ZipCodeLocation, error) {
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("zipcodes: invalid place name pattern %s %v", pattern, err)
+	}
+
+	locations := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if expression.MatchString(elm.PlaceName) {
+			locations = append(locations, elm)
+		}
+		return true
+	})
+
+	sort.Slice(locations, func(i, j int) bool {
+		return zc.keyOf(locations[i]) < zc.keyOf(locations[j])
+	})
+	return locations, nil
+}
@@ -0,0 +1,112 @@
+package zipcodes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFoldPlaceName(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Expected string
+	}{
+		{"Düsseldorf", "dusseldorf"},
+		{"Köln", "koln"},
+		{"Ko\u0308ln", "koln"},
+		{"Łódź", "lodz"},
+		{"Straße", "strasse"},
+		{" Île-de-France ", "ile-de-france"},
+		{"Hamburg", "hamburg"},
+		{"Hạ Long", "ha long"},
+		{"Huế", "hue"},
+		{"HỒ CHÍ MINH", "ho chi minh"},
+		{"Guǎngzhōu", "guangzhou"},
+		{"ǍǏǑǓ ǎǐǒǔ", "aiou aiou"},
+		{"Ørestad", "orestad"},
+	}
+	for _, c := range cases {
+		if folded := foldPlaceName(c.Name); folded != c.Expected {
+			t.Errorf("Unexpected folded name for %s. Got %s, want %s", c.Name, folded, c.Expected)
+		}
+	}
+}
+
+func TestLookupByPlaceName(t *testing.T) {
+	cases := []struct {
+		Name         string
+		ExpectedList []string
+	}{
+		{"dusseldorf", []string{"40210", "40211"}},
+		{"DÜSSELDORF", []string{"40210", "40211"}},
+		{"Koln", []string{"50667"}},
+		{"lodz", []string{"90-001"}},
+		{"malmo", []string{"211 11"}},
+		{"paris 01", []string{"75001"}},
+	}
+	for _, opts := range [][]Option{{}, {WithPlaceNameIndex()}} {
+		zipcodesDataset, err := New("datasets/accents_dataset.txt", opts...)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+		}
+		for _, c := range cases {
+			locations, err := zipcodesDataset.LookupByPlaceName(c.Name)
+			if err != nil {
+				t.Errorf("Unexpected error while looking for %s %v", c.Name, err)
+			}
+			list := []string{}
+			for _, elm := range locations {
+				list = append(list, elm.ZipCode)
+			}
+			if reflect.DeepEqual(list, c.ExpectedList) != true {
+				t.Errorf("LookupByPlaceName returned an unexpected list for %s. Got %v, want %v", c.Name, list, c.ExpectedList)
+			}
+		}
+
+		locations, _ := zipcodesDataset.LookupByPlaceName("koln")
+		if len(locations) != 1 || locations[0].PlaceName != "Köln" {
+			t.Errorf("LookupByPlaceName should keep the original place name. Got %v", locations)
+		}
+
+		// Failing case
+		_, err = zipcodesDataset.LookupByPlaceName("Berlin")
+		if err == nil || err.Error() != "zipcodes: place name Berlin not found !" {
+			t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: place name Berlin not found !")
+		}
+	}
+}
+
+func TestLookupByPlaceNameRegex(t *testing.T) {
+	cases := []struct {
+		Pattern      string
+		ExpectedList []string
+	}{
+		{"^Hamburg", []string{"20457", "22525"}},
+		{"n$", []string{"01945", "19053", "87787"}},
+		{"(?i)^h", []string{"20457", "22525", "94051"}},
+		{"berg$", []string{"94051"}},
+		{"^X", []string{}},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		locations, err := zipcodesDataset.LookupByPlaceNameRegex(c.Pattern)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for %s %v", c.Pattern, err)
+		}
+		list := []string{}
+		for _, elm := range locations {
+			list = append(list, elm.ZipCode)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("LookupByPlaceNameRegex returned an unexpected list for %s. Got %v, want %v", c.Pattern, list, c.ExpectedList)
+		}
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.LookupByPlaceNameRegex("(")
+	if err == nil || err.Error() != "zipcodes: invalid place name pattern ( error parsing regexp: missing closing ): `(`" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: invalid place name pattern ( error parsing regexp: missing closing ): `(`")
+	}
+}
@@ -0,0 +1,336 @@
+package zipcodes
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// LineIssue describes a problem found on a given line of a dataset
+type LineIssue struct {
+	Line   int
+	Reason string
+}
+
+// Report summarizes the result of validating a dataset with InspectDataset
+type Report struct {
+	TotalLines           int
+	ParsedRecords        int
+	BadLines             []LineIssue
+	Duplicates           int
+	CoordinateViolations []LineIssue
+}
+
+// ZipMove describes a zipcode whose coordinates changed between two datasets.
+// ZipCode holds the key of the row, see WithKeyFunc.
+type ZipMove struct {
+	ZipCode  string
+	OldLat   float64
+	OldLon   float64
+	NewLat   float64
+	NewLon   float64
+	Distance float64
+}
+
+// Outliers returns the zipcodes whose nearest neighbor sharing the same
+// state code is farther than radiusKm, which usually points to a row with
+// wrong coordinates. Zipcodes that are the only entry of their state are
+// not reported since there is nothing to compare them with. Like
+// MostIsolatedZipCodes, the neighbors are searched in the spatial index.
+func (zc *Zipcodes) Outliers(radiusKm float64) []ZipCodeLocation {
+	located := make(map[string]int)
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			located[elm.StateCode]++
+		}
+		return true
+	})
+
+	outliers := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if !elm.HasCoordinates() || located[elm.StateCode] < 2 {
+			return true
+		}
+		sameState := func(other ZipCodeLocation) bool {
+			return other.StateCode == elm.StateCode
+		}
+		if neighbors := zc.kNearestWhere(elm, 1, sameState); len(neighbors) == 1 && neighbors[0].Distance > radiusKm {
+			outliers = append(outliers, elm)
+		}
+		return true
+	})
+
+	sort.Slice(outliers, func(i, j int) bool {
+		return zc.keyOf(outliers[i]) < zc.keyOf(outliers[j])
+	})
+	return outliers
+}
+
+// InspectDataset parses a dataset without loading it and reports every line
+// that could not be parsed, the number of repeated zipcodes and the records
+// whose coordinates are out of range. The returned error is only set when
+// the reader itself fails.
+func InspectDataset(r io.Reader) (Report, error) {
+	report := Report{BadLines: []LineIssue{}, CoordinateViolations: []LineIssue{}}
+	seen := make(map[string]bool)
+
+	options := loadOptions{}
+	scanner := options.newScanner(r)
+	for scanner.Scan() {
+		report.TotalLines++
+		location, err := parseLine(scanner.Text(), options)
+		if err != nil {
+			report.BadLines = append(report.BadLines, LineIssue{Line: report.TotalLines, Reason: err.Error()})
+			continue
+		}
+		report.ParsedRecords++
+
+		if seen[location.ZipCode] {
+			report.Duplicates++
+		}
+		seen[location.ZipCode] = true
+
+		if location.Lat < -90 || location.Lat > 90 || location.Lon < -180 || location.Lon > 180 {
+			report.CoordinateViolations = append(report.CoordinateViolations, LineIssue{
+				Line:   report.TotalLines,
+				Reason: fmt.Sprintf("zipcodes: coordinates %v/%v out of range", location.Lat, location.Lon),
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return report, fmt.Errorf("zipcodes: dataset line longer than %d bytes", options.lineLengthLimit())
+		}
+		return report, fmt.Errorf("zipcodes: error while reading dataset %v", err)
+	}
+	return report, nil
+}
+
+// DedupeByPlaceWithinKm returns the dataset with entries sharing the same place
+// name collapsed when they are within radius Kilometers of each other. Entries
+// are grouped transitively (A near B and B near C puts A, B and C in the same
+// group) and each group is represented by the entry with the lowest zipcode.
+// Entries without coordinates are kept as they are. The list is sorted by zipcode.
+func (zc *Zipcodes) DedupeByPlaceWithinKm(radius float64) []ZipCodeLocation {
+	groups, deduped := zc.placeGroupsWithinKm(radius)
+	for _, group := range groups {
+		deduped = append(deduped, group[0])
+	}
+
+	sort.Slice(deduped, func(i, j int) bool {
+		return zc.keyOf(deduped[i]) < zc.keyOf(deduped[j])
+	})
+	return deduped
+}
+
+// FindNearDuplicates returns the groups of zipcodes sharing the same place name
+// within maxKm Kilometers of each other, which usually are the same place
+// entered twice with slightly different codes, e.g. after overlaying a
+// correction dataset. Zipcodes are grouped the same way as in
+// DedupeByPlaceWithinKm, only groups of several zipcodes are returned, each
+// sorted by zipcode and ordered by their first zipcode.
+func (zc *Zipcodes) FindNearDuplicates(maxKm float64) [][]string {
+	groups, _ := zc.placeGroupsWithinKm(maxKm)
+	duplicates := [][]string{}
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		zipCodes := make([]string, len(group))
+		for i, elm := range group {
+			zipCodes[i] = zc.keyOf(elm)
+		}
+		duplicates = append(duplicates, zipCodes)
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i][0] < duplicates[j][0]
+	})
+	return duplicates
+}
+
+// placeGroupsWithinKm groups transitively the entries sharing the same place
+// name within radius Kilometers of each other, each group sorted by zipcode.
+// Entries without coordinates are returned apart.
+func (zc *Zipcodes) placeGroupsWithinKm(radius float64) ([][]ZipCodeLocation, []ZipCodeLocation) {
+	byPlace := make(map[string][]ZipCodeLocation)
+	unlocated := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			byPlace[elm.PlaceName] = append(byPlace[elm.PlaceName], elm)
+		} else {
+			unlocated = append(unlocated, elm)
+		}
+		return true
+	})
+
+	groups := [][]ZipCodeLocation{}
+	for _, locations := range byPlace {
+		sort.Slice(locations, func(i, j int) bool {
+			return zc.keyOf(locations[i]) < zc.keyOf(locations[j])
+		})
+		merged := make([]bool, len(locations))
+		for i := range locations {
+			if merged[i] {
+				continue
+			}
+			// locations[i] has the lowest zipcode of its group, absorb
+			// every entry reachable from it within the radius
+			group := []ZipCodeLocation{locations[i]}
+			merged[i] = true
+			queue := []int{i}
+			for len(queue) > 0 {
+				current := locations[queue[0]]
+				queue = queue[1:]
+				for j := range locations {
+					if merged[j] {
+						continue
+					}
+					distance := DistanceBetweenPoints(current.Lat, current.Lon, locations[j].Lat, locations[j].Lon, earthRadiusKm)
+					if distance <= radius {
+						merged[j] = true
+						queue = append(queue, j)
+						group = append(group, locations[j])
+					}
+				}
+			}
+			sort.Slice(group, func(a, b int) bool {
+				return zc.keyOf(group[a]) < zc.keyOf(group[b])
+			})
+			groups = append(groups, group)
+		}
+	}
+	return groups, unlocated
+}
+
+// MovedZipcodes compares two versions of a dataset and returns the zipcodes
+// present in both whose coordinates moved farther than thresholdKm, with the
+// distance moved in Kilometers, sorted by zipcode. It helps reviewing an update
+// before deploying it. Zipcodes added, removed or without coordinates in either
+// version are not reported.
+func MovedZipcodes(old, new *Zipcodes, thresholdKm float64) ([]ZipMove, error) {
+	if old == nil || new == nil {
+		return nil, fmt.Errorf("zipcodes: both datasets are required")
+	}
+	if thresholdKm < 0 {
+		return nil, fmt.Errorf("zipcodes: threshold must not be negative")
+	}
+
+	moves := []ZipMove{}
+	new.Range(func(current ZipCodeLocation) bool {
+		previous, found := old.Get(old.keyOf(current))
+		if !found || !previous.HasCoordinates() || !current.HasCoordinates() {
+			return true
+		}
+		distance := DistanceBetweenPoints(previous.Lat, previous.Lon, current.Lat, current.Lon, earthRadiusKm)
+		if distance > thresholdKm {
+			moves = append(moves, ZipMove{
+				ZipCode:  new.keyOf(current),
+				OldLat:   previous.Lat,
+				OldLon:   previous.Lon,
+				NewLat:   current.Lat,
+				NewLon:   current.Lon,
+				Distance: distance,
+			})
+		}
+		return true
+	})
+
+	sort.Slice(moves, func(i, j int) bool {
+		return moves[i].ZipCode < moves[j].ZipCode
+	})
+	return moves, nil
+}
+
+// CoincidentCoordinates groups the zipcodes sharing exactly the same
+// coordinates, which usually points to duplicated centroids skewing density
+// and clustering results. Only coordinates shared by several zipcodes are
+// returned, keyed by lat/lon, each group sorted by zipcode. Zipcodes without
+// coordinates are left out.
+func (zc *Zipcodes) CoincidentCoordinates() map[[2]float64][]string {
+	byCoordinates := make(map[[2]float64][]string)
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			key := [2]float64{elm.Lat, elm.Lon}
+			byCoordinates[key] = append(byCoordinates[key], zc.keyOf(elm))
+		}
+		return true
+	})
+
+	for key, zipCodes := range byCoordinates {
+		if len(zipCodes) < 2 {
+			delete(byCoordinates, key)
+			continue
+		}
+		sort.Strings(zipCodes)
+	}
+	return byCoordinates
+}
+
+// SameCoordinates returns the other zipcodes located exactly at the same
+// coordinates as zipCode, sorted by zipcode, which explains distance-zero
+// results caused by PO boxes or merged entries. The list is empty when the
+// zipcode shares its position with no other one.
+func (zc *Zipcodes) SameCoordinates(zipCode string) ([]ZipCodeLocation, error) {
+	location, err := zc.lookupWithCoordinates(zipCode)
+	if err != nil {
+		return nil, err
+	}
+
+	twins := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if zc.keyOf(elm) != zc.keyOf(*location) && elm.HasCoordinates() && elm.Lat == location.Lat && elm.Lon == location.Lon {
+			twins = append(twins, elm)
+		}
+		return true
+	})
+
+	sort.Slice(twins, func(i, j int) bool {
+		return zc.keyOf(twins[i]) < zc.keyOf(twins[j])
+	})
+	return twins, nil
+}
+
+// SuspectSignFlips returns the zipcodes of a state whose latitude or longitude
+// sign differs from the one shared by most zipcodes of the state, sorted by
+// zipcode. Such rows are almost always sign-flipped import errors. An axis
+// where no sign holds a strict majority is not checked, and states crossing
+// the equator or the prime meridian report false positives. Zipcodes without
+// coordinates are left out.
+func (zc *Zipcodes) SuspectSignFlips(stateCode string) ([]ZipCodeLocation, error) {
+	locations, err := zc.zipcodesInState(stateCode)
+	if err != nil {
+		return nil, err
+	}
+
+	// balance is the number of positive values minus the number of negative ones
+	latBalance, lonBalance := 0, 0
+	for _, elm := range locations {
+		if elm.HasCoordinates() {
+			latBalance += signOf(elm.Lat)
+			lonBalance += signOf(elm.Lon)
+		}
+	}
+
+	suspects := []ZipCodeLocation{}
+	for _, elm := range locations {
+		if !elm.HasCoordinates() {
+			continue
+		}
+		if signOf(elm.Lat)*latBalance < 0 || signOf(elm.Lon)*lonBalance < 0 {
+			suspects = append(suspects, elm)
+		}
+	}
+	return suspects, nil
+}
+
+// signOf returns 1 for positive values and zero, -1 for negative ones
+func signOf(value float64) int {
+	if value < 0 {
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,295 @@
+package zipcodes
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestOutliers(t *testing.T) {
+	cases := []struct {
+		RadiusKm     float64
+		ExpectedList []string
+	}{
+		{
+			10,
+			[]string{"01945", "03058", "87787", "94051"},
+		},
+		{
+			100,
+			[]string{"87787", "94051"},
+		},
+		{
+			1000,
+			[]string{},
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		list := []string{}
+		for _, elm := range zipcodesDataset.Outliers(c.RadiusKm) {
+			list = append(list, elm.ZipCode)
+		}
+
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("Outliers returned an unexpected zipcode list. Got %v, want %v", list, c.ExpectedList)
+		}
+	}
+}
+
+func BenchmarkOutliers(b *testing.B) {
+	zipcodesDataset, err := New(writeBenchmarkDataset(b, 5000))
+	if err != nil {
+		b.Fatalf("Unexpected error while initializing struct %v", err)
+	}
+	zipcodesDataset.WarmUp()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zipcodesDataset.Outliers(10)
+	}
+}
+
+func TestInspectDataset(t *testing.T) {
+	file, err := os.Open("datasets/inspect_dataset.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error while opening dataset %v", err)
+	}
+	defer file.Close()
+
+	report, err := InspectDataset(file)
+	if err != nil {
+		t.Errorf("Unexpected error while inspecting dataset %v", err)
+	}
+	expectedReport := Report{
+		TotalLines:    5,
+		ParsedRecords: 3,
+		BadLines: []LineIssue{
+			{Line: 2, Reason: "zipcodes: error while converting WRONG to Latitude"},
+			{Line: 4, Reason: "zipcodes: file line does not have 12 fields"},
+		},
+		Duplicates: 1,
+		CoordinateViolations: []LineIssue{
+			{Line: 5, Reason: "zipcodes: coordinates 153.6313/11.4092 out of range"},
+		},
+	}
+
+	if reflect.DeepEqual(report, expectedReport) != true {
+		t.Errorf("Unexpected report. Got %+v, want %+v", report, expectedReport)
+	}
+}
+
+func TestDedupeByPlaceWithinKm(t *testing.T) {
+	cases := []struct {
+		Radius       float64
+		ExpectedList []string
+	}{
+		{
+			0.1,
+			[]string{"10115", "10117", "10119", "15234", "20457", "22525"},
+		},
+		{
+			3,
+			[]string{"10115", "15234", "20457", "22525"},
+		},
+		{
+			1000,
+			[]string{"10115", "20457", "22525"},
+		},
+	}
+	zipcodesDataset, err := New("datasets/dedupe_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		list := []string{}
+		for _, elm := range zipcodesDataset.DedupeByPlaceWithinKm(c.Radius) {
+			list = append(list, elm.ZipCode)
+		}
+
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("DedupeByPlaceWithinKm returned an unexpected zipcode list. Got %v, want %v", list, c.ExpectedList)
+		}
+	}
+}
+
+func TestFindNearDuplicates(t *testing.T) {
+	cases := []struct {
+		MaxKm    float64
+		Expected [][]string
+	}{
+		{0.1, [][]string{}},
+		{1.5, [][]string{{"10115", "10119"}}},
+		{3, [][]string{{"10115", "10117", "10119"}}},
+		{1000, [][]string{{"10115", "10117", "10119", "15234"}}},
+	}
+	zipcodesDataset, err := New("datasets/dedupe_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		duplicates := zipcodesDataset.FindNearDuplicates(c.MaxKm)
+		if reflect.DeepEqual(duplicates, c.Expected) != true {
+			t.Errorf("FindNearDuplicates returned unexpected groups within %v Km. Got %v, want %v", c.MaxKm, duplicates, c.Expected)
+		}
+	}
+}
+
+func TestMovedZipcodes(t *testing.T) {
+	oldDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	newDataset, err := New("datasets/moved_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	cases := []struct {
+		ThresholdKm float64
+		Expected    []ZipMove
+	}{
+		{
+			0,
+			[]ZipMove{
+				{ZipCode: "01945", OldLat: 51.4167, OldLon: 13.9333, NewLat: 51.4257, NewLon: 13.9333, Distance: 1},
+				{ZipCode: "20457", OldLat: 53.5497, OldLon: 9.9794, NewLat: 53.0497, NewLon: 9.9794, Distance: 55.6},
+			},
+		},
+		{
+			5,
+			[]ZipMove{
+				{ZipCode: "20457", OldLat: 53.5497, OldLon: 9.9794, NewLat: 53.0497, NewLon: 9.9794, Distance: 55.6},
+			},
+		},
+		{
+			100,
+			[]ZipMove{},
+		},
+	}
+	for _, c := range cases {
+		moves, err := MovedZipcodes(oldDataset, newDataset, c.ThresholdKm)
+		if err != nil {
+			t.Errorf("Unexpected error while comparing datasets %v", err)
+		}
+		if reflect.DeepEqual(moves, c.Expected) != true {
+			t.Errorf("MovedZipcodes returned an unexpected list. Got %+v, want %+v", moves, c.Expected)
+		}
+	}
+
+	// Failing cases
+	_, err = MovedZipcodes(nil, newDataset, 1)
+	if err == nil || err.Error() != "zipcodes: both datasets are required" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: both datasets are required")
+	}
+	_, err = MovedZipcodes(oldDataset, newDataset, -1)
+	if err == nil || err.Error() != "zipcodes: threshold must not be negative" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: threshold must not be negative")
+	}
+}
+
+func TestCoincidentCoordinates(t *testing.T) {
+	zipcodesDataset, err := New("datasets/coincident_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	expected := map[[2]float64][]string{
+		{48.1374, 11.5755}: {"80331", "80333", "80335"},
+		{50.1109, 8.6821}:  {"60311", "60313"},
+	}
+	if groups := zipcodesDataset.CoincidentCoordinates(); reflect.DeepEqual(groups, expected) != true {
+		t.Errorf("CoincidentCoordinates returned unexpected groups. Got %v, want %v", groups, expected)
+	}
+
+	zipcodesDataset, err = New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if groups := zipcodesDataset.CoincidentCoordinates(); len(groups) != 0 {
+		t.Errorf("CoincidentCoordinates returned unexpected groups. Got %v, want none", groups)
+	}
+}
+
+func TestSameCoordinates(t *testing.T) {
+	zipcodesDataset, err := New("datasets/coincident_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	cases := []struct {
+		ZipCode      string
+		ExpectedList []string
+	}{
+		{"80331", []string{"80333", "80335"}},
+		{"80335", []string{"80331", "80333"}},
+		{"60311", []string{"60313"}},
+		{"60314", []string{}},
+		{"20457", []string{}},
+	}
+	for _, c := range cases {
+		twins, err := zipcodesDataset.SameCoordinates(c.ZipCode)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for %s %v", c.ZipCode, err)
+		}
+		list := []string{}
+		for _, elm := range twins {
+			list = append(list, elm.ZipCode)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("SameCoordinates returned an unexpected list for %s. Got %v, want %v", c.ZipCode, list, c.ExpectedList)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.SameCoordinates("00000")
+	if err == nil || err.Error() != "zipcodes: zipcode 00000 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 00000 not found !")
+	}
+	_, err = zipcodesDataset.SameCoordinates("96798")
+	if err == nil || err.Error() != "zipcodes: zipcode 96798 has no coordinates" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 96798 has no coordinates")
+	}
+}
+
+func TestSuspectSignFlips(t *testing.T) {
+	cases := []struct {
+		StateCode    string
+		ExpectedList []string
+	}{
+		{"NY", []string{"10004", "10005"}},
+		// one zipcode on each side, no majority
+		{"AS", []string{}},
+	}
+	zipcodesDataset, err := New("datasets/sign_flip_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		suspects, err := zipcodesDataset.SuspectSignFlips(c.StateCode)
+		if err != nil {
+			t.Errorf("Unexpected error while checking %s %v", c.StateCode, err)
+		}
+		list := []string{}
+		for _, elm := range suspects {
+			list = append(list, elm.ZipCode)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("SuspectSignFlips returned an unexpected list for %s. Got %v, want %v", c.StateCode, list, c.ExpectedList)
+		}
+	}
+
+	zipcodesDataset, err = New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if suspects, _ := zipcodesDataset.SuspectSignFlips("BB"); len(suspects) != 0 {
+		t.Errorf("SuspectSignFlips returned unexpected zipcodes. Got %v, want none", suspects)
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.SuspectSignFlips("XX")
+	if err == nil || err.Error() != "zipcodes: state XX not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: state XX not found !")
+	}
+}
@@ -0,0 +1,60 @@
+package zipcodes
+
+import (
+	"sort"
+	"sync"
+)
+
+// keyIndex holds the zipcodes of a dataset sorted lexically, built once on demand
+type keyIndex struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+// sortedKeys returns every zipcode of the dataset in lexical order. Like the
+// spatial index, the slice is a snapshot rebuilt when entries are added to or
+// removed from DatasetList or after InvalidateIndex. Structs not created by
+// this package have no key index and sort the zipcodes on every call.
+func (zc *Zipcodes) sortedKeys() []string {
+	if zc.keys == nil {
+		return collectSortedKeys(zc)
+	}
+	zc.keys.mu.Lock()
+	defer zc.keys.mu.Unlock()
+	if zc.keys.keys == nil || (zc.source == nil && len(zc.keys.keys) != len(zc.DatasetList)) {
+		zc.keys.keys = collectSortedKeys(zc)
+	}
+	return zc.keys.keys
+}
+
+// collectSortedKeys returns the keys of the dataset sorted lexically, the
+// zipcodes unless the dataset was loaded WithKeyFunc
+func collectSortedKeys(zc *Zipcodes) []string {
+	keys := []string{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		keys = append(keys, zc.keyOf(elm))
+		return true
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// ZipCodesInRange returns the zipcodes lexically between low and high, both
+// included, sorted by zipcode, e.g. every zipcode from "01000" to "01999".
+// Bounds are found by binary search over the sorted zipcodes, so only the
+// entries of the range are read.
+func (zc *Zipcodes) ZipCodesInRange(low, high string) []ZipCodeLocation {
+	keys := zc.sortedKeys()
+	first := sort.SearchStrings(keys, low)
+	last := sort.Search(len(keys), func(i int) bool {
+		return keys[i] > high
+	})
+
+	locations := []ZipCodeLocation{}
+	for i := first; i < last; i++ {
+		if location, found := zc.Get(keys[i]); found {
+			locations = append(locations, location)
+		}
+	}
+	return locations
+}
@@ -0,0 +1,47 @@
+package zipcodes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestZipCodesInRange(t *testing.T) {
+	cases := []struct {
+		Low          string
+		High         string
+		ExpectedList []string
+	}{
+		{"01000", "01999", []string{"01945"}},
+		{"01945", "20457", []string{"01945", "03058", "19053", "20457"}},
+		{"2", "3", []string{"20457", "22525"}},
+		{"", "~", []string{"01945", "03058", "19053", "20457", "22525", "34134", "87787", "94051"}},
+		{"95000", "99999", []string{}},
+		{"20457", "01945", []string{}},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		list := []string{}
+		for _, elm := range zipcodesDataset.ZipCodesInRange(c.Low, c.High) {
+			list = append(list, elm.ZipCode)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("ZipCodesInRange returned an unexpected list for %s-%s. Got %v, want %v", c.Low, c.High, list, c.ExpectedList)
+		}
+	}
+
+	// Entries added after the first query are picked up
+	zipcodesDataset.DatasetList["01500"] = ZipCodeLocation{ZipCode: "01500", Lat: 51, Lon: 13}
+	locations := zipcodesDataset.ZipCodesInRange("01000", "01999")
+	if len(locations) != 2 || locations[0].ZipCode != "01500" {
+		t.Errorf("ZipCodesInRange did not pick up the added zipcode. Got %v", locations)
+	}
+
+	// Structs created outside of the package have no key index
+	literal := &Zipcodes{DatasetList: zipcodesDataset.DatasetList}
+	if len(literal.ZipCodesInRange("01000", "01999")) != 2 {
+		t.Errorf("ZipCodesInRange returned an unexpected list without key index")
+	}
+}
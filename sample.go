@@ -0,0 +1,53 @@
+package zipcodes
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// SampleWeightedByDensity returns n zipcodes drawn at random, with replacement,
+// each zipcode being as likely to be drawn as the number of zipcodes within
+// radiusKm of it plus one, so dense urban areas show up more often than rural
+// ones while isolated zipcodes can still be drawn. The given source makes the
+// sample reproducible, a nil source draws from one seeded with the current
+// time. Zipcodes without coordinates are never drawn. Every weight is a radius
+// query, which scans a whole LocationSource unless WarmUp was called on it.
+func (zc *Zipcodes) SampleWeightedByDensity(n int, radiusKm float64, r *rand.Rand) []ZipCodeLocation {
+	candidates := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if elm.HasCoordinates() {
+			candidates = append(candidates, elm)
+		}
+		return true
+	})
+	sample := []ZipCodeLocation{}
+	if n < 1 || len(candidates) == 0 {
+		return sample
+	}
+	// the dataset iteration order is random, sort so a seeded source
+	// always draws the same zipcodes
+	sort.Slice(candidates, func(i, j int) bool {
+		return zc.keyOf(candidates[i]) < zc.keyOf(candidates[j])
+	})
+
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	cumulative := make([]int, len(candidates))
+	total := 0
+	for i := range candidates {
+		total += len(zc.FindZipcodesWithinRadius(&candidates[i], radiusKm, earthRadiusKm)) + 1
+		cumulative[i] = total
+	}
+
+	for len(sample) < n {
+		drawn := r.Intn(total)
+		i := sort.Search(len(cumulative), func(i int) bool {
+			return cumulative[i] > drawn
+		})
+		sample = append(sample, candidates[i])
+	}
+	return sample
+}
@@ -0,0 +1,47 @@
+package zipcodes
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSampleWeightedByDensity(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	// Within 100 Km the Hamburg zipcodes have 2 neighbors, the Brandenburg
+	// ones 1 and the others none, so they weigh 3, 2 and 1 out of 16
+	expectedWeights := map[string]float64{
+		"20457": 3, "22525": 3, "19053": 3,
+		"01945": 2, "03058": 2,
+		"34134": 1, "87787": 1, "94051": 1,
+	}
+	draws := 16000
+	counts := make(map[string]int)
+	for _, elm := range zipcodesDataset.SampleWeightedByDensity(draws, 100, rand.New(rand.NewSource(1))) {
+		counts[elm.ZipCode]++
+	}
+	for zipCode, weight := range expectedWeights {
+		expected := float64(draws) * weight / 16
+		if math.Abs(float64(counts[zipCode])-expected) > expected*0.15 {
+			t.Errorf("Unexpected amount of draws for %s. Got %d, want about %v", zipCode, counts[zipCode], expected)
+		}
+	}
+
+	first := zipcodesDataset.SampleWeightedByDensity(10, 100, rand.New(rand.NewSource(42)))
+	second := zipcodesDataset.SampleWeightedByDensity(10, 100, rand.New(rand.NewSource(42)))
+	if len(first) != 10 || reflect.DeepEqual(first, second) != true {
+		t.Errorf("SampleWeightedByDensity is not reproducible with the same source. Got %v and %v", first, second)
+	}
+
+	if sample := zipcodesDataset.SampleWeightedByDensity(0, 100, rand.New(rand.NewSource(1))); len(sample) != 0 {
+		t.Errorf("Unexpected sample for n = 0. Got %v", sample)
+	}
+	if sample := zipcodesDataset.SampleWeightedByDensity(5, 100, nil); len(sample) != 5 {
+		t.Errorf("Unexpected sample without a source. Got %v", sample)
+	}
+}
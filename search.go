@@ -0,0 +1,271 @@
+package zipcodes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// commonPrefixLength returns the number of leading bytes shared by a and b
+func commonPrefixLength(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// LookupNearestPrefix looks for a zipcode and, when it is not in the dataset,
+// falls back to the existing zipcode sharing the longest prefix with it. If
+// several zipcodes share that prefix, the one closest to their centroid is
+// returned. This is a heuristic fallback, the result is not the requested zipcode.
+func (zc *Zipcodes) LookupNearestPrefix(zipCode string) (*ZipCodeLocation, error) {
+	return zc.LookupNearestPrefixContext(context.Background(), zipCode)
+}
+
+// LookupNearestPrefixContext works like LookupNearestPrefix but stops the scan
+// of the dataset and returns the context error once ctx is done
+func (zc *Zipcodes) LookupNearestPrefixContext(ctx context.Context, zipCode string) (*ZipCodeLocation, error) {
+	if location, err := zc.LookupContext(ctx, zipCode); err == nil {
+		return location, nil
+	}
+
+	longest := 0
+	candidates := []ZipCodeLocation{}
+	err := zc.rangeContext(ctx, func(elm ZipCodeLocation) bool {
+		length := commonPrefixLength(zipCode, elm.ZipCode)
+		if length > longest {
+			longest = length
+			candidates = candidates[:0]
+		}
+		if length == longest && length > 0 {
+			candidates = append(candidates, elm)
+		}
+		return true
+	})
+	if err != nil {
+		return &ZipCodeLocation{}, err
+	}
+	if len(candidates) == 0 {
+		return &ZipCodeLocation{}, fmt.Errorf("zipcodes: no zipcode shares a prefix with %s", zipCode)
+	}
+
+	return zc.centralLocation(candidates), nil
+}
+
+// centralLocation returns the location closest to the centroid of the list.
+// Locations without coordinates are only picked when no other one has them.
+func (zc *Zipcodes) centralLocation(locations []ZipCodeLocation) *ZipCodeLocation {
+	withCoordinates := []ZipCodeLocation{}
+	weights := []float64{}
+	for _, elm := range locations {
+		if elm.HasCoordinates() {
+			withCoordinates = append(withCoordinates, elm)
+			weights = append(weights, 1)
+		}
+	}
+	if len(withCoordinates) == 0 {
+		withCoordinates = locations
+	}
+
+	best := withCoordinates[0]
+	lat, lon, err := sphericalCentroid(withCoordinates, weights)
+	if err != nil {
+		for _, elm := range withCoordinates {
+			if zc.keyOf(elm) < zc.keyOf(best) {
+				best = elm
+			}
+		}
+		return &best
+	}
+
+	bestDistance := math.Inf(1)
+	for _, elm := range withCoordinates {
+		distance := DistanceBetweenPoints(lat, lon, elm.Lat, elm.Lon, earthRadiusKm)
+		if closer(distance, zc.keyOf(elm), bestDistance, zc.keyOf(best)) {
+			best = elm
+			bestDistance = distance
+		}
+	}
+	return &best
+}
+
+// ZipSuggestion is a zipcode suggested for a partial or mistyped query, with
+// a score between 0 and 1, the higher the better
+type ZipSuggestion struct {
+	ZipCode   string
+	PlaceName string
+	Score     float64
+}
+
+// SuggestZipcodes returns up to limit zipcodes matching a partial or mistyped
+// query, e.g. for an autocomplete, sorted by descending score then zipcode.
+// The comparison ignores case and surrounding spaces and the score is:
+//   - 0.5 + 0.5 × len(query) / len(zipcode) for zipcodes starting with the
+//     query, so an exact match scores 1 and prefix matches score above 0.5
+//   - 0.5 × (1 - edits / len(query)) for the other zipcodes, edits being the
+//     number of insertions, deletions, substitutions or swaps of adjacent
+//     characters turning the query into the start of the zipcode. They are only
+//     suggested with at most one edit per 3 characters of the query, so their
+//     score stays below 0.34.
+func (zc *Zipcodes) SuggestZipcodes(query string, limit int) []ZipSuggestion {
+	suggestions, _ := zc.SuggestZipcodesContext(context.Background(), query, limit)
+	return suggestions
+}
+
+// SuggestZipcodesContext works like SuggestZipcodes but stops the scan of the
+// dataset and returns the context error once ctx is done
+func (zc *Zipcodes) SuggestZipcodesContext(ctx context.Context, query string, limit int) ([]ZipSuggestion, error) {
+	suggestions := []ZipSuggestion{}
+	normalized := []rune(strings.ToUpper(strings.TrimSpace(query)))
+	if len(normalized) == 0 || limit <= 0 {
+		return suggestions, nil
+	}
+
+	maxEdits := len(normalized) / 3
+	err := zc.rangeContext(ctx, func(elm ZipCodeLocation) bool {
+		zipCode := []rune(strings.ToUpper(elm.ZipCode))
+		start := zipCode
+		if len(start) > len(normalized) {
+			start = start[:len(normalized)]
+		}
+		var score float64
+		if string(start) == string(normalized) {
+			score = 0.5 + 0.5*float64(len(normalized))/float64(len(zipCode))
+		} else if edits := editDistance(normalized, start); edits <= maxEdits {
+			score = 0.5 * (1 - float64(edits)/float64(len(normalized)))
+		} else {
+			return true
+		}
+		suggestions = append(suggestions, ZipSuggestion{ZipCode: elm.ZipCode, PlaceName: elm.PlaceName, Score: score})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].ZipCode < suggestions[j].ZipCode
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and
+// swaps of adjacent runes needed to turn a into b
+func editDistance(a, b []rune) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			best := rows[i-1][j-1] + cost
+			if rows[i-1][j]+1 < best {
+				best = rows[i-1][j] + 1
+			}
+			if rows[i][j-1]+1 < best {
+				best = rows[i][j-1] + 1
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && rows[i-2][j-2]+1 < best {
+				best = rows[i-2][j-2] + 1
+			}
+			rows[i][j] = best
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+// zipcodesWithPrefix returns the locations whose zipcode starts with prefix, sorted by zipcode
+func (zc *Zipcodes) zipcodesWithPrefix(prefix string) []ZipCodeLocation {
+	locations := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if strings.HasPrefix(elm.ZipCode, prefix) {
+			locations = append(locations, elm)
+		}
+		return true
+	})
+
+	sort.Slice(locations, func(i, j int) bool {
+		return zc.keyOf(locations[i]) < zc.keyOf(locations[j])
+	})
+	return locations
+}
+
+// isFiveDigitZip reports whether the zipcode is made of exactly five digits
+func isFiveDigitZip(zipCode string) bool {
+	return len(zipCode) == 5 && isDigits(zipCode)
+}
+
+// isDigits reports whether s is made only of ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// CanonicalizeZip cleans up a US zipcode so lookups get consistent keys:
+//   - surrounding spaces are trimmed
+//   - the ZIP+4 extension is dropped, "12345-6789" and "123456789" give "12345"
+//   - leading zeros lost by spreadsheets are restored, "1945" gives "01945"
+//   - zipcodes of other lengths, and those starting with 000 which is not an
+//     assigned range, are rejected
+//
+// Codes with other characters than digits, e.g. "211 11" or "SW1A 1AA", are
+// not US zipcodes and are returned untouched.
+func CanonicalizeZip(raw string) (string, error) {
+	zipCode := strings.TrimSpace(raw)
+	if zipCode == "" {
+		return "", fmt.Errorf("zipcodes: zipcode is empty")
+	}
+	if len(zipCode) == 10 && zipCode[5] == '-' && isDigits(zipCode[:5]) && isDigits(zipCode[6:]) {
+		zipCode = zipCode[:5]
+	}
+	if !isDigits(zipCode) {
+		return raw, nil
+	}
+
+	switch len(zipCode) {
+	case 9:
+		zipCode = zipCode[:5]
+	case 3, 4:
+		zipCode = strings.Repeat("0", 5-len(zipCode)) + zipCode
+	case 5:
+	default:
+		return "", fmt.Errorf("zipcodes: %s is not a valid US zipcode", raw)
+	}
+	if strings.HasPrefix(zipCode, "000") {
+		return "", fmt.Errorf("zipcodes: %s is not in an assigned US zipcode range", raw)
+	}
+	return zipCode, nil
+}
+
+// SectionalCenter returns the sectional center facility (SCF) of a US zipcode,
+// that is its first three digits, together with every zipcode of the dataset
+// sharing them, sorted by zipcode
+func (zc *Zipcodes) SectionalCenter(zipCode string) (string, []ZipCodeLocation, error) {
+	if !isFiveDigitZip(zipCode) {
+		return "", nil, fmt.Errorf("zipcodes: zipcode %s is not a 5 digit zipcode", zipCode)
+	}
+	scf := zipCode[:3]
+	return scf, zc.zipcodesWithPrefix(scf), nil
+}
@@ -0,0 +1,271 @@
+package zipcodes
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLookupNearestPrefix(t *testing.T) {
+	cases := []struct {
+		ZipCode          string
+		ExpectedResponse string
+	}{
+		{
+			"01945",
+			"01945",
+		},
+		{
+			"01946",
+			"01945",
+		},
+		{
+			"22999",
+			"22525",
+		},
+		{
+			"2",
+			"20457",
+		},
+		{
+			"9",
+			"94051",
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		location, err := zipcodesDataset.LookupNearestPrefix(c.ZipCode)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s", err)
+		}
+		if location.ZipCode != c.ExpectedResponse {
+			t.Errorf("Unexpected zipcode returned for %s. Got %s, want %s", c.ZipCode, location.ZipCode, c.ExpectedResponse)
+		}
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.LookupNearestPrefix("XYZ")
+	if err == nil || err.Error() != "zipcodes: no zipcode shares a prefix with XYZ" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: no zipcode shares a prefix with XYZ")
+	}
+}
+
+func TestSectionalCenter(t *testing.T) {
+	cases := []struct {
+		ZipCode      string
+		ExpectedSCF  string
+		ExpectedList []string
+	}{
+		{
+			"01945",
+			"019",
+			[]string{"01945"},
+		},
+		{
+			"20400",
+			"204",
+			[]string{"20457"},
+		},
+		{
+			"55555",
+			"555",
+			[]string{},
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		scf, locations, err := zipcodesDataset.SectionalCenter(c.ZipCode)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for sectional center %v", err)
+		}
+		list := []string{}
+		for _, elm := range locations {
+			list = append(list, elm.ZipCode)
+		}
+		if scf != c.ExpectedSCF || reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("Unexpected sectional center. Got %s/%v, want %s/%v", scf, list, c.ExpectedSCF, c.ExpectedList)
+		}
+	}
+
+	// Failing cases
+	for _, zipCode := range []string{"1945", "019456", "0194A", ""} {
+		_, _, err := zipcodesDataset.SectionalCenter(zipCode)
+		if err == nil || err.Error() != "zipcodes: zipcode "+zipCode+" is not a 5 digit zipcode" {
+			t.Errorf("Unexpected error for %s. Got %v", zipCode, err)
+		}
+	}
+}
+
+func TestSuggestZipcodes(t *testing.T) {
+	cases := []struct {
+		Query    string
+		Limit    int
+		Expected []ZipSuggestion
+	}{
+		{"20457", 3, []ZipSuggestion{{"20457", "Hamburg Neustadt", 1}}},
+		{" 204 ", 3, []ZipSuggestion{{"20457", "Hamburg Neustadt", 0.8}}},
+		{"2", 3, []ZipSuggestion{{"20457", "Hamburg Neustadt", 0.6}, {"22525", "Hamburg Eidelstedt", 0.6}}},
+		{"2", 1, []ZipSuggestion{{"20457", "Hamburg Neustadt", 0.6}}},
+		{"02457", 3, []ZipSuggestion{{"20457", "Hamburg Neustadt", 0.4}}},
+		{"19035", 3, []ZipSuggestion{{"19053", "Schwerin", 0.4}}},
+		{"345", 3, []ZipSuggestion{{"34134", "Kassel", 0.33333333333333337}}},
+		{"x", 3, []ZipSuggestion{}},
+		{"", 3, []ZipSuggestion{}},
+		{"20457", 0, []ZipSuggestion{}},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		if suggestions := zipcodesDataset.SuggestZipcodes(c.Query, c.Limit); reflect.DeepEqual(suggestions, c.Expected) != true {
+			t.Errorf("Unexpected suggestions for %q. Got %v, want %v", c.Query, suggestions, c.Expected)
+		}
+	}
+
+	zipcodesDataset, err = New("datasets/accents_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	expected := []ZipSuggestion{{"211 11", "Malmö", 0.9166666666666667}}
+	if suggestions := zipcodesDataset.SuggestZipcodes("211 1", 5); reflect.DeepEqual(suggestions, expected) != true {
+		t.Errorf("Unexpected suggestions. Got %v, want %v", suggestions, expected)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		A, B     string
+		Expected int
+	}{
+		{"20457", "20457", 0},
+		{"20457", "20475", 1},
+		{"20457", "2057", 1},
+		{"20457", "120457", 1},
+		{"20457", "30458", 2},
+		{"", "abc", 3},
+	}
+	for _, c := range cases {
+		if edits := editDistance([]rune(c.A), []rune(c.B)); edits != c.Expected {
+			t.Errorf("Unexpected edit distance between %s and %s. Got %d, want %d", c.A, c.B, edits, c.Expected)
+		}
+	}
+}
+
+func TestCanonicalizeZip(t *testing.T) {
+	cases := []struct {
+		Raw      string
+		Expected string
+	}{
+		{"01945", "01945"},
+		{" 20457 ", "20457"},
+		{"20457-1234", "20457"},
+		{"204571234", "20457"},
+		{"1945", "01945"},
+		{"501", "00501"},
+		{"211 11", "211 11"},
+		{"90-001", "90-001"},
+		{" SW1A 1AA", " SW1A 1AA"},
+	}
+	for _, c := range cases {
+		zipCode, err := CanonicalizeZip(c.Raw)
+		if err != nil {
+			t.Errorf("Unexpected error while canonicalizing %q %v", c.Raw, err)
+		}
+		if zipCode != c.Expected {
+			t.Errorf("Unexpected canonical zipcode for %q. Got %q, want %q", c.Raw, zipCode, c.Expected)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		Raw         string
+		ExpectedErr string
+	}{
+		{"12", "zipcodes: 12 is not a valid US zipcode"},
+		{"123456", "zipcodes: 123456 is not a valid US zipcode"},
+		{" ", "zipcodes: zipcode is empty"},
+		{"00000", "zipcodes: 00000 is not in an assigned US zipcode range"},
+		{"99", "zipcodes: 99 is not a valid US zipcode"},
+		{"00012-3456", "zipcodes: 00012-3456 is not in an assigned US zipcode range"},
+	}
+	for _, c := range fail {
+		_, err := CanonicalizeZip(c.Raw)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+// cancellingSource is a LocationSource calling cancel once it has visited
+// after locations, to cancel a context in the middle of a scan
+type cancellingSource struct {
+	sliceSource
+	after   int
+	cancel  context.CancelFunc
+	visited *int
+}
+
+func (s cancellingSource) Range(fn func(ZipCodeLocation) bool) {
+	s.sliceSource.Range(func(location ZipCodeLocation) bool {
+		*s.visited++
+		if *s.visited == s.after {
+			s.cancel()
+		}
+		return fn(location)
+	})
+}
+
+func TestScanContextCancellation(t *testing.T) {
+	locations := sliceSource{}
+	for i := 0; i < 5000; i++ {
+		locations = append(locations, ZipCodeLocation{ZipCode: fmt.Sprintf("%05d", i), PlaceName: "Place", Lat: 50, Lon: 10})
+	}
+
+	// A live context scans the whole dataset
+	location, err := NewFromSource(locations).LookupNearestPrefixContext(context.Background(), "0499X")
+	if err != nil || location.ZipCode != "04990" {
+		t.Errorf("Unexpected location. Got %v %v, want %s", location.ZipCode, err, "04990")
+	}
+	suggestions, err := NewFromSource(locations).SuggestZipcodesContext(context.Background(), "0123", 1)
+	if err != nil || reflect.DeepEqual(suggestions, []ZipSuggestion{{"01230", "Place", 0.9}}) != true {
+		t.Errorf("Unexpected suggestions. Got %v %v", suggestions, err)
+	}
+
+	// Failing cases
+	scans := []func(ctx context.Context, zc *Zipcodes) error{
+		func(ctx context.Context, zc *Zipcodes) error {
+			_, err := zc.LookupNearestPrefixContext(ctx, "0499X")
+			return err
+		},
+		func(ctx context.Context, zc *Zipcodes) error {
+			_, err := zc.SuggestZipcodesContext(ctx, "0123", 1)
+			return err
+		},
+	}
+	for i, scan := range scans {
+		ctx, cancel := context.WithCancel(context.Background())
+		visited := 0
+		source := cancellingSource{sliceSource: locations, after: 1500, cancel: cancel, visited: &visited}
+		err := scan(ctx, NewFromSource(source))
+		if err != context.Canceled {
+			t.Errorf("Unexpected error for scan %d. Got %v, want %v", i, err, context.Canceled)
+		}
+		if visited != 2048 {
+			t.Errorf("Scan %d should stop at the first check after the cancellation. Visited %d locations, want %d", i, visited, 2048)
+		}
+
+		visited = 0
+		err = scan(ctx, NewFromSource(source))
+		if err != context.Canceled || visited != 0 {
+			t.Errorf("Scan %d should not start with a done context. Got %v after %d locations", i, err, visited)
+		}
+	}
+}
@@ -0,0 +1,391 @@
+package zipcodes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// StateSummary holds the number of zipcodes of a state and their bounding box
+type StateSummary struct {
+	Count  int
+	MinLat float64
+	MaxLat float64
+	MinLon float64
+	MaxLon float64
+}
+
+// CoverageReport counts the zipcodes of a dataset by the facility sets that cover them
+type CoverageReport struct {
+	Both    int
+	OnlyA   int
+	OnlyB   int
+	Neither int
+}
+
+// CrossStatePair links a zipcode to its nearest neighbor when that neighbor
+// belongs to another state, with the distance between them in Kilometers
+type CrossStatePair struct {
+	ZipCodeLocation
+	Neighbor ZipCodeLocation
+	Distance float64
+}
+
+// zipcodesInState returns the locations of a state sorted by zipcode, comparing
+// state codes regardless of case like IsInState, or an error when the state
+// has no zipcodes in the dataset
+func (zc *Zipcodes) zipcodesInState(stateCode string) ([]ZipCodeLocation, error) {
+	locations := []ZipCodeLocation{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if strings.EqualFold(elm.StateCode, strings.TrimSpace(stateCode)) {
+			locations = append(locations, elm)
+		}
+		return true
+	})
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("zipcodes: state %s not found !", stateCode)
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		return zc.keyOf(locations[i]) < zc.keyOf(locations[j])
+	})
+	return locations, nil
+}
+
+// IsInState reports whether the dataset puts the zipcode in the given state,
+// comparing state codes regardless of case, e.g. to cross-check third party
+// address data
+func (zc *Zipcodes) IsInState(zipCode, stateCode string) (bool, error) {
+	location, err := zc.Lookup(zipCode)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(location.StateCode, strings.TrimSpace(stateCode)), nil
+}
+
+// UncoveredZipcodes returns the zipcodes of a state that have none of the
+// facilities within radiusKm, sorted by zipcode. Zipcodes without
+// coordinates can not be covered and are always returned.
+func (zc *Zipcodes) UncoveredZipcodes(stateCode string, facilities []string, radiusKm float64) ([]string, error) {
+	sites, err := zc.locations(facilities)
+	if err != nil {
+		return nil, err
+	}
+	locations, err := zc.zipcodesInState(stateCode)
+	if err != nil {
+		return nil, err
+	}
+
+	uncovered := []string{}
+	for _, elm := range locations {
+		if !isCovered(elm, sites, radiusKm) {
+			uncovered = append(uncovered, zc.keyOf(elm))
+		}
+	}
+	return uncovered, nil
+}
+
+// StateRadiusCoverage returns the fraction (between 0 and 1) of the zipcodes
+// of a state within radiusKm of the center zipcode, which tells how
+// centralized the state is around it. The center does not have to belong to
+// the state, and the state zipcodes without coordinates count as not covered.
+func (zc *Zipcodes) StateRadiusCoverage(stateCode, centerZip string, radiusKm float64) (float64, error) {
+	center, errLoc := zc.lookupWithCoordinates(centerZip)
+	if errLoc != nil {
+		return 0, errLoc
+	}
+	locations, err := zc.zipcodesInState(stateCode)
+	if err != nil {
+		return 0, err
+	}
+
+	covered := 0
+	for _, elm := range locations {
+		if isCovered(elm, []ZipCodeLocation{*center}, radiusKm) {
+			covered++
+		}
+	}
+	return float64(covered) / float64(len(locations)), nil
+}
+
+// isCovered reports whether any of the sites is within radiusKm of the location
+func isCovered(location ZipCodeLocation, sites []ZipCodeLocation, radiusKm float64) bool {
+	if !location.HasCoordinates() {
+		return false
+	}
+	for _, site := range sites {
+		if DistanceBetweenPoints(location.Lat, location.Lon, site.Lat, site.Lon, earthRadiusKm) < radiusKm {
+			return true
+		}
+	}
+	return false
+}
+
+// CoverageComparison counts the zipcodes of the dataset covered within radiusKm
+// by both facility sets, by only one of them or by neither, e.g. to compare
+// two store layouts. Zipcodes without coordinates are counted as covered by neither.
+func (zc *Zipcodes) CoverageComparison(setA, setB []string, radiusKm float64) (CoverageReport, error) {
+	sitesA, err := zc.locations(setA)
+	if err != nil {
+		return CoverageReport{}, err
+	}
+	sitesB, err := zc.locations(setB)
+	if err != nil {
+		return CoverageReport{}, err
+	}
+
+	report := CoverageReport{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		coveredA := isCovered(elm, sitesA, radiusKm)
+		coveredB := isCovered(elm, sitesB, radiusKm)
+		switch {
+		case coveredA && coveredB:
+			report.Both++
+		case coveredA:
+			report.OnlyA++
+		case coveredB:
+			report.OnlyB++
+		default:
+			report.Neither++
+		}
+		return true
+	})
+	return report, nil
+}
+
+// WeightedCoverageFraction returns the share (between 0 and 1) of the total
+// weight carried by the zipcodes within radiusKm of any seed, e.g. the
+// fraction of the order volume living close to a store. Zipcodes absent from
+// weights count as 0, while weighted zipcodes missing from the dataset or
+// without coordinates count as not covered.
+func (zc *Zipcodes) WeightedCoverageFraction(seeds []string, radiusKm float64, weights map[string]float64) (float64, error) {
+	sites, err := zc.locations(seeds)
+	if err != nil {
+		return 0, err
+	}
+
+	// sum in zipcode order so the result does not depend on map iteration
+	zipCodes := make([]string, 0, len(weights))
+	for zipCode, weight := range weights {
+		if weight < 0 {
+			return 0, fmt.Errorf("zipcodes: weight for zipcode %s must not be negative", zipCode)
+		}
+		zipCodes = append(zipCodes, zipCode)
+	}
+	sort.Strings(zipCodes)
+
+	total, covered := 0.0, 0.0
+	for _, zipCode := range zipCodes {
+		total += weights[zipCode]
+		if location, found := zc.Get(zipCode); found && isCovered(location, sites, radiusKm) {
+			covered += weights[zipCode]
+		}
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("zipcodes: total weight must be greater than zero")
+	}
+	return covered / total, nil
+}
+
+// StateSummaries returns, for every state code of the dataset, the number of
+// zipcodes and the bounding box of those with coordinates
+func (zc *Zipcodes) StateSummaries() map[string]StateSummary {
+	summaries := make(map[string]StateSummary)
+	located := make(map[string]bool)
+	zc.Range(func(elm ZipCodeLocation) bool {
+		summary := summaries[elm.StateCode]
+		summary.Count++
+		if elm.HasCoordinates() {
+			if !located[elm.StateCode] {
+				summary.MinLat, summary.MaxLat = elm.Lat, elm.Lat
+				summary.MinLon, summary.MaxLon = elm.Lon, elm.Lon
+				located[elm.StateCode] = true
+			}
+			summary.MinLat = math.Min(summary.MinLat, elm.Lat)
+			summary.MaxLat = math.Max(summary.MaxLat, elm.Lat)
+			summary.MinLon = math.Min(summary.MinLon, elm.Lon)
+			summary.MaxLon = math.Max(summary.MaxLon, elm.Lon)
+		}
+		summaries[elm.StateCode] = summary
+		return true
+	})
+	return summaries
+}
+
+// StateCenter returns the zipcode of a state closest to the centroid of all
+// its zipcodes, e.g. to place a single marker for the state on a map. Zipcodes
+// without coordinates are only returned when none of the state has them.
+func (zc *Zipcodes) StateCenter(stateCode string) (*ZipCodeLocation, error) {
+	locations, err := zc.zipcodesInState(stateCode)
+	if err != nil {
+		return &ZipCodeLocation{}, err
+	}
+	return zc.centralLocation(locations), nil
+}
+
+// LatitudeRankInState returns the 1-based rank of the zipcode among the
+// zipcodes of its state from north to south, together with the number of
+// zipcodes of the state, e.g. rank 1 is the northernmost one. Zipcodes at the
+// same latitude are ranked by zipcode and those without coordinates are not counted.
+func (zc *Zipcodes) LatitudeRankInState(zipCode string) (rank, total int, err error) {
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
+	if errLoc != nil {
+		return 0, 0, errLoc
+	}
+	locations, err := zc.zipcodesInState(location.StateCode)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rank = 1
+	for _, elm := range locations {
+		if !elm.HasCoordinates() {
+			continue
+		}
+		total++
+		if elm.Lat > location.Lat || (elm.Lat == location.Lat && zc.keyOf(elm) < zc.keyOf(*location)) {
+			rank++
+		}
+	}
+	return rank, total, nil
+}
+
+// AdminPath returns the administrative hierarchy of a zipcode, from the
+// country code down to the first, second and third level admin names, e.g.
+// for breadcrumbs. Levels left blank in the dataset are skipped.
+func (zc *Zipcodes) AdminPath(zipCode string) ([]string, error) {
+	location, err := zc.Lookup(zipCode)
+	if err != nil {
+		return nil, err
+	}
+
+	path := []string{}
+	for _, level := range []string{location.CountryCode, location.AdminName, location.AdminName2, location.AdminName3} {
+		if strings.TrimSpace(level) != "" {
+			path = append(path, level)
+		}
+	}
+	return path, nil
+}
+
+// DistancesFromState returns every zipcode of a state with its distance in
+// Kilometers to toZip, sorted by distance, e.g. to report the closest and
+// farthest customers of a state. Zipcodes without coordinates are left out.
+func (zc *Zipcodes) DistancesFromState(stateCode, toZip string) ([]ZipCodeWithDistance, error) {
+	target, errLoc := zc.lookupWithCoordinates(toZip)
+	if errLoc != nil {
+		return nil, errLoc
+	}
+	locations, err := zc.zipcodesInState(stateCode)
+	if err != nil {
+		return nil, err
+	}
+
+	center := newPoint(*target)
+	distances := []ZipCodeWithDistance{}
+	for _, elm := range locations {
+		if elm.HasCoordinates() {
+			distances = append(distances, ZipCodeWithDistance{
+				ZipCodeLocation: elm,
+				Distance:        center.distanceTo(newPoint(elm), earthRadiusKm),
+			})
+		}
+	}
+	zc.sortByDistance(distances)
+	return distances, nil
+}
+
+// distinctValues returns the sorted unique non blank values of a field
+func (zc *Zipcodes) distinctValues(field func(ZipCodeLocation) string) []string {
+	seen := make(map[string]bool)
+	values := []string{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		value := field(elm)
+		if strings.TrimSpace(value) != "" && !seen[value] {
+			seen[value] = true
+			values = append(values, value)
+		}
+		return true
+	})
+	sort.Strings(values)
+	return values
+}
+
+// DistinctStates returns the sorted state codes present in the dataset, e.g.
+// to fill a filter dropdown
+func (zc *Zipcodes) DistinctStates() []string {
+	return zc.distinctValues(func(elm ZipCodeLocation) string {
+		return elm.StateCode
+	})
+}
+
+// DistinctAdminNames returns the sorted first level admin names present in the dataset
+func (zc *Zipcodes) DistinctAdminNames() []string {
+	return zc.distinctValues(func(elm ZipCodeLocation) string {
+		return elm.AdminName
+	})
+}
+
+// DistinctCountries returns the sorted country codes present in the dataset
+func (zc *Zipcodes) DistinctCountries() []string {
+	return zc.distinctValues(func(elm ZipCodeLocation) string {
+		return elm.CountryCode
+	})
+}
+
+// RankInStateByDistance returns the 1-based rank of targetZip among the
+// zipcodes of the state ordered by distance to referenceZip, together with the
+// number of zipcodes of the state, e.g. rank 3 is the third closest one.
+// State codes are compared regardless of case. Equidistant zipcodes are ranked
+// by zipcode and those without coordinates are not counted.
+func (zc *Zipcodes) RankInStateByDistance(stateCode, referenceZip, targetZip string) (rank, total int, err error) {
+	target, errLoc := zc.lookupWithCoordinates(targetZip)
+	if errLoc != nil {
+		return 0, 0, errLoc
+	}
+	if !strings.EqualFold(target.StateCode, strings.TrimSpace(stateCode)) {
+		return 0, 0, fmt.Errorf("zipcodes: zipcode %s is not in state %s", targetZip, stateCode)
+	}
+	distances, err := zc.DistancesFromState(stateCode, referenceZip)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i, elm := range distances {
+		if zc.keyOf(elm.ZipCodeLocation) == zc.keyOf(*target) {
+			rank = i + 1
+		}
+	}
+	return rank, len(distances), nil
+}
+
+// CrossStateNeighbors returns the zipcodes whose nearest neighbor belongs to
+// another state, each with that neighbor and the distance to it, sorted by
+// zipcode. They mark the state borders, e.g. for cross-border deliveries or
+// tax edge cases. Ties go to the lowest zipcode and, like MostIsolatedZipCodes,
+// the neighbors are searched in the spatial index. Zipcodes without
+// coordinates are left out.
+func (zc *Zipcodes) CrossStateNeighbors() ([]CrossStatePair, error) {
+	located := 0
+	pairs := []CrossStatePair{}
+	zc.Range(func(elm ZipCodeLocation) bool {
+		if !elm.HasCoordinates() {
+			return true
+		}
+		located++
+		neighbor, found := zc.nearestNeighbor(elm)
+		if found && neighbor.StateCode != elm.StateCode {
+			pairs = append(pairs, CrossStatePair{ZipCodeLocation: elm, Neighbor: neighbor.ZipCodeLocation, Distance: neighbor.Distance})
+		}
+		return true
+	})
+	if located < 2 {
+		return nil, fmt.Errorf("zipcodes: dataset needs at least two zipcodes with coordinates")
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return zc.keyOf(pairs[i].ZipCodeLocation) < zc.keyOf(pairs[j].ZipCodeLocation)
+	})
+	return pairs, nil
+}
@@ -0,0 +1,515 @@
+package zipcodes
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestUncoveredZipcodes(t *testing.T) {
+	cases := []struct {
+		StateCode    string
+		Facilities   []string
+		RadiusKm     float64
+		ExpectedList []string
+	}{
+		{
+			"BB",
+			[]string{"01945"},
+			10,
+			[]string{"03058"},
+		},
+		{
+			"BB",
+			[]string{"01945"},
+			50,
+			[]string{},
+		},
+		{
+			"HH",
+			[]string{"19053", "34134"},
+			50,
+			[]string{"20457", "22525"},
+		},
+		{
+			"BB",
+			[]string{},
+			1000,
+			[]string{"01945", "03058"},
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		list, err := zipcodesDataset.UncoveredZipcodes(c.StateCode, c.Facilities, c.RadiusKm)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for uncovered zipcodes %v", err)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("UncoveredZipcodes returned an unexpected zipcode list. Got %v, want %v", list, c.ExpectedList)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.UncoveredZipcodes("XX", []string{"01945"}, 10)
+	if err == nil || err.Error() != "zipcodes: state XX not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: state XX not found !")
+	}
+	_, err = zipcodesDataset.UncoveredZipcodes("BB", []string{"11111"}, 10)
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+}
+
+func TestStateSummaries(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	expected := map[string]StateSummary{
+		"BB": {Count: 2, MinLat: 51.4167, MaxLat: 51.6865, MinLon: 13.9333, MaxLon: 14.5094},
+		"BY": {Count: 2, MinLat: 47.8935, MaxLat: 48.6496, MinLon: 10.2672, MaxLon: 13.6265},
+		"HE": {Count: 1, MinLat: 51.2878, MaxLat: 51.2878, MinLon: 9.4705, MaxLon: 9.4705},
+		"HH": {Count: 2, MinLat: 53.5497, MaxLat: 53.605, MinLon: 9.9161, MaxLon: 9.9794},
+		"MV": {Count: 1, MinLat: 53.6313, MaxLat: 53.6313, MinLon: 11.4092, MaxLon: 11.4092},
+	}
+
+	summaries := zipcodesDataset.StateSummaries()
+	if reflect.DeepEqual(summaries, expected) != true {
+		t.Errorf("Unexpected state summaries. Got %v, want %v", summaries, expected)
+	}
+}
+
+func TestStateCenter(t *testing.T) {
+	cases := []struct {
+		StateCode string
+		Expected  string
+	}{
+		{"BB", "01945"},
+		{"BY", "87787"},
+		{"HH", "20457"},
+		{"MV", "19053"},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		location, err := zipcodesDataset.StateCenter(c.StateCode)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for the center of %s %v", c.StateCode, err)
+		}
+		if location.ZipCode != c.Expected {
+			t.Errorf("StateCenter returned an unexpected zipcode for %s. Got %s, want %s", c.StateCode, location.ZipCode, c.Expected)
+		}
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.StateCenter("XX")
+	if err == nil || err.Error() != "zipcodes: state XX not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: state XX not found !")
+	}
+}
+
+func TestCoverageComparison(t *testing.T) {
+	cases := []struct {
+		SetA     []string
+		SetB     []string
+		RadiusKm float64
+		Expected CoverageReport
+	}{
+		{[]string{"20457"}, []string{"19053", "01945"}, 100, CoverageReport{Both: 3, OnlyA: 0, OnlyB: 2, Neither: 3}},
+		{[]string{"20457"}, []string{}, 300, CoverageReport{Both: 0, OnlyA: 4, OnlyB: 0, Neither: 4}},
+		{[]string{}, []string{}, 100, CoverageReport{Both: 0, OnlyA: 0, OnlyB: 0, Neither: 8}},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		report, err := zipcodesDataset.CoverageComparison(c.SetA, c.SetB, c.RadiusKm)
+		if err != nil {
+			t.Errorf("Unexpected error while comparing coverage %v", err)
+		}
+		if report != c.Expected {
+			t.Errorf("CoverageComparison returned an unexpected report. Got %+v, want %+v", report, c.Expected)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.CoverageComparison([]string{"11111"}, []string{"01945"}, 10)
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+	_, err = zipcodesDataset.CoverageComparison([]string{"01945"}, []string{"22222"}, 10)
+	if err == nil || err.Error() != "zipcodes: zipcode 22222 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 22222 not found !")
+	}
+}
+
+func TestWeightedCoverageFraction(t *testing.T) {
+	cases := []struct {
+		Seeds    []string
+		RadiusKm float64
+		Weights  map[string]float64
+		Expected float64
+	}{
+		{[]string{"20457"}, 100, map[string]float64{"20457": 2, "19053": 1, "94051": 1}, 0.75},
+		{[]string{"20457"}, 100, map[string]float64{"22525": 4, "99999": 4}, 0.5},
+		{[]string{"20457", "94051"}, 100, map[string]float64{"20457": 2, "19053": 1, "94051": 1}, 1},
+		{[]string{"20457"}, 100, map[string]float64{"20457": 0, "94051": 3}, 0},
+		{[]string{}, 100, map[string]float64{"20457": 1}, 0},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		fraction, err := zipcodesDataset.WeightedCoverageFraction(c.Seeds, c.RadiusKm, c.Weights)
+		if err != nil {
+			t.Errorf("Unexpected error while computing weighted coverage %v", err)
+		}
+		if fraction != c.Expected {
+			t.Errorf("Unexpected weighted coverage around %v within %v Km. Got %v, want %v", c.Seeds, c.RadiusKm, fraction, c.Expected)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.WeightedCoverageFraction([]string{"11111"}, 100, map[string]float64{"20457": 1})
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+	_, err = zipcodesDataset.WeightedCoverageFraction([]string{"20457"}, 100, map[string]float64{"20457": -1})
+	if err == nil || err.Error() != "zipcodes: weight for zipcode 20457 must not be negative" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: weight for zipcode 20457 must not be negative")
+	}
+	_, err = zipcodesDataset.WeightedCoverageFraction([]string{"20457"}, 100, map[string]float64{})
+	if err == nil || err.Error() != "zipcodes: total weight must be greater than zero" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: total weight must be greater than zero")
+	}
+}
+
+func TestLatitudeRankInState(t *testing.T) {
+	cases := []struct {
+		ZipCode       string
+		ExpectedRank  int
+		ExpectedTotal int
+	}{
+		{"03058", 1, 2},
+		{"01945", 2, 2},
+		{"22525", 1, 2},
+		{"20457", 2, 2},
+		{"34134", 1, 1},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		rank, total, err := zipcodesDataset.LatitudeRankInState(c.ZipCode)
+		if err != nil {
+			t.Errorf("Unexpected error while ranking %s %v", c.ZipCode, err)
+		}
+		if rank != c.ExpectedRank || total != c.ExpectedTotal {
+			t.Errorf("Unexpected rank for %s. Got %d/%d, want %d/%d", c.ZipCode, rank, total, c.ExpectedRank, c.ExpectedTotal)
+		}
+	}
+
+	// Zipcodes at the same latitude are ranked by zipcode
+	tieDataset, err := New("datasets/tie_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for zipCode, expectedRank := range map[string]int{"T0": 1, "T1": 2, "T2": 3} {
+		rank, total, _ := tieDataset.LatitudeRankInState(zipCode)
+		if rank != expectedRank || total != 3 {
+			t.Errorf("Unexpected rank for %s. Got %d/%d, want %d/%d", zipCode, rank, total, expectedRank, 3)
+		}
+	}
+
+	// Failing case
+	_, _, err = zipcodesDataset.LatitudeRankInState("XYZ")
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestStateRadiusCoverage(t *testing.T) {
+	cases := []struct {
+		StateCode string
+		CenterZip string
+		RadiusKm  float64
+		Expected  float64
+	}{
+		{"BB", "01945", 10, 0.5},
+		{"BB", "01945", 50, 1},
+		{"HH", "19053", 95, 0.5},
+		{"HH", "19053", 50, 0},
+		{"BY", "20457", 1000, 1},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		fraction, err := zipcodesDataset.StateRadiusCoverage(c.StateCode, c.CenterZip, c.RadiusKm)
+		if err != nil {
+			t.Errorf("Unexpected error while computing state coverage %v", err)
+		}
+		if fraction != c.Expected {
+			t.Errorf("Unexpected coverage of %s around %s within %v Km. Got %v, want %v", c.StateCode, c.CenterZip, c.RadiusKm, fraction, c.Expected)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.StateRadiusCoverage("XX", "01945", 10)
+	if err == nil || err.Error() != "zipcodes: state XX not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: state XX not found !")
+	}
+	_, err = zipcodesDataset.StateRadiusCoverage("BB", "11111", 10)
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+}
+
+func TestIsInState(t *testing.T) {
+	cases := []struct {
+		ZipCode   string
+		StateCode string
+		Expected  bool
+	}{
+		{"01945", "BB", true},
+		{"01945", "bb", true},
+		{"01945", " Bb ", true},
+		{"01945", "BY", false},
+		{"20457", "HH", true},
+		{"20457", "", false},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		inState, err := zipcodesDataset.IsInState(c.ZipCode, c.StateCode)
+		if err != nil {
+			t.Errorf("Unexpected error while checking the state of %s %v", c.ZipCode, err)
+		}
+		if inState != c.Expected {
+			t.Errorf("Unexpected result for %s in %q. Got %v, want %v", c.ZipCode, c.StateCode, inState, c.Expected)
+		}
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.IsInState("XYZ", "BB")
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestAdminPath(t *testing.T) {
+	cases := []struct {
+		ZipCode  string
+		Expected []string
+	}{
+		{"94051", []string{"DE", "Bayern", "Lower Bavaria", "Landkreis Passau"}},
+		{"34134", []string{"DE", "Hessen", "Regierungsbezirk Kassel", "Kassel, documenta-Stadt"}},
+		{"01945", []string{"DE", "Brandenburg", "Landkreis Oberspreewald-Lausitz"}},
+		{"19053", []string{"DE", "Mecklenburg-Vorpommern", "Schwerin"}},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		path, err := zipcodesDataset.AdminPath(c.ZipCode)
+		if err != nil {
+			t.Errorf("Unexpected error while building the admin path of %s %v", c.ZipCode, err)
+		}
+		if reflect.DeepEqual(path, c.Expected) != true {
+			t.Errorf("Unexpected admin path for %s. Got %v, want %v", c.ZipCode, path, c.Expected)
+		}
+	}
+
+	// Failing case
+	_, err = zipcodesDataset.AdminPath("XYZ")
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestDistancesFromState(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	cases := []struct {
+		StateCode string
+		Expected  []ZipCodeWithDistance
+	}{
+		{"BB", []ZipCodeWithDistance{
+			{ZipCodeLocation: zipcodesDataset.DatasetList["01945"], Distance: 357.59},
+			{ZipCodeLocation: zipcodesDataset.DatasetList["03058"], Distance: 369.28},
+		}},
+		{"HH", []ZipCodeWithDistance{
+			{ZipCodeLocation: zipcodesDataset.DatasetList["20457"], Distance: 0},
+			{ZipCodeLocation: zipcodesDataset.DatasetList["22525"], Distance: 7.43},
+		}},
+		{"BY", []ZipCodeWithDistance{
+			{ZipCodeLocation: zipcodesDataset.DatasetList["94051"], Distance: 601.25},
+			{ZipCodeLocation: zipcodesDataset.DatasetList["87787"], Distance: 629.27},
+		}},
+	}
+	for _, c := range cases {
+		distances, err := zipcodesDataset.DistancesFromState(c.StateCode, "20457")
+		if err != nil {
+			t.Errorf("Unexpected error while computing distances from %s %v", c.StateCode, err)
+		}
+		if reflect.DeepEqual(distances, c.Expected) != true {
+			t.Errorf("Unexpected distances from %s. Got %v, want %v", c.StateCode, distances, c.Expected)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.DistancesFromState("XX", "20457")
+	if err == nil || err.Error() != "zipcodes: state XX not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: state XX not found !")
+	}
+	_, err = zipcodesDataset.DistancesFromState("BB", "XYZ")
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestDistinctValues(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	cases := []struct {
+		Name     string
+		Values   []string
+		Expected []string
+	}{
+		{"DistinctStates", zipcodesDataset.DistinctStates(), []string{"BB", "BY", "HE", "HH", "MV"}},
+		{"DistinctAdminNames", zipcodesDataset.DistinctAdminNames(), []string{"Bayern", "Brandenburg", "Hamburg", "Hessen", "Mecklenburg-Vorpommern"}},
+		{"DistinctCountries", zipcodesDataset.DistinctCountries(), []string{"DE"}},
+		{"DistinctStates of an empty dataset", NewFromSource(sliceSource{}).DistinctStates(), []string{}},
+	}
+	for _, c := range cases {
+		if reflect.DeepEqual(c.Values, c.Expected) != true {
+			t.Errorf("%s returned unexpected values. Got %v, want %v", c.Name, c.Values, c.Expected)
+		}
+	}
+
+	zipcodesDataset, err = New("datasets/blank_coordinates_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if countries := zipcodesDataset.DistinctCountries(); reflect.DeepEqual(countries, []string{"AS", "DE"}) != true {
+		t.Errorf("DistinctCountries returned unexpected values. Got %v, want %v", countries, []string{"AS", "DE"})
+	}
+}
+
+func TestRankInStateByDistance(t *testing.T) {
+	cases := []struct {
+		StateCode     string
+		ReferenceZip  string
+		TargetZip     string
+		ExpectedRank  int
+		ExpectedTotal int
+	}{
+		{"BB", "20457", "01945", 1, 2},
+		{"BB", "20457", "03058", 2, 2},
+		{"BY", "20457", "87787", 2, 2},
+		{"HH", "20457", "20457", 1, 2},
+		{"HH", "19053", "22525", 2, 2},
+		{"bb", "20457", "03058", 2, 2},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		rank, total, err := zipcodesDataset.RankInStateByDistance(c.StateCode, c.ReferenceZip, c.TargetZip)
+		if err != nil {
+			t.Errorf("Unexpected error while ranking %s %v", c.TargetZip, err)
+		}
+		if rank != c.ExpectedRank || total != c.ExpectedTotal {
+			t.Errorf("Unexpected rank of %s. Got %d/%d, want %d/%d", c.TargetZip, rank, total, c.ExpectedRank, c.ExpectedTotal)
+		}
+	}
+
+	// Failing cases
+	fail := []struct {
+		StateCode    string
+		ReferenceZip string
+		TargetZip    string
+		ExpectedErr  string
+	}{
+		{"BB", "20457", "XYZ", "zipcodes: zipcode XYZ not found !"},
+		{"BB", "XYZ", "01945", "zipcodes: zipcode XYZ not found !"},
+		{"BY", "20457", "01945", "zipcodes: zipcode 01945 is not in state BY"},
+	}
+	for _, c := range fail {
+		_, _, err := zipcodesDataset.RankInStateByDistance(c.StateCode, c.ReferenceZip, c.TargetZip)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
+func TestCrossStateNeighbors(t *testing.T) {
+	cases := []struct {
+		Dataset  string
+		Opts     []Option
+		Expected []string
+	}{
+		{"datasets/valid_dataset.txt", nil, []string{"19053 MV 20457 HH 94.8", "34134 HE 20457 HH 253.87"}},
+		{"datasets/dedupe_dataset.txt", nil, []string{"15234 BB 10119 BE 80.27"}},
+		{"datasets/blank_coordinates_dataset.txt", []Option{WithBlankCoordinates()}, []string{}},
+	}
+	for _, c := range cases {
+		zipcodesDataset, err := New(c.Dataset, c.Opts...)
+		if err != nil {
+			t.Errorf("Unexpected error while initializing struct %v", err)
+			continue
+		}
+		pairs, err := zipcodesDataset.CrossStateNeighbors()
+		if err != nil {
+			t.Errorf("Unexpected error while looking for cross state neighbors %v", err)
+		}
+		list := []string{}
+		for _, pair := range pairs {
+			list = append(list, fmt.Sprintf("%s %s %s %s %v", pair.ZipCode, pair.StateCode, pair.Neighbor.ZipCode, pair.Neighbor.StateCode, pair.Distance))
+		}
+		if reflect.DeepEqual(list, c.Expected) != true {
+			t.Errorf("CrossStateNeighbors returned an unexpected list for %s. Got %v, want %v", c.Dataset, list, c.Expected)
+		}
+	}
+
+	// Failing case
+	zipcodesDataset, err := New("datasets/empty_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	_, err = zipcodesDataset.CrossStateNeighbors()
+	if err == nil || err.Error() != "zipcodes: dataset needs at least two zipcodes with coordinates" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: dataset needs at least two zipcodes with coordinates")
+	}
+}
+
+func BenchmarkCrossStateNeighbors(b *testing.B) {
+	zipcodesDataset, err := New(writeBenchmarkDataset(b, 5000))
+	if err != nil {
+		b.Fatalf("Unexpected error while initializing struct %v", err)
+	}
+	zipcodesDataset.WarmUp()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := zipcodesDataset.CrossStateNeighbors(); err != nil {
+			b.Fatalf("Unexpected error while looking for cross state neighbors %v", err)
+		}
+	}
+}
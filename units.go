@@ -0,0 +1,89 @@
+package zipcodes
+
+import (
+	"fmt"
+	"math"
+)
+
+// Unit is a unit in which distances can be returned
+type Unit int
+
+const (
+	// Kilometers distances are rounded to 2 decimals (10 meters)
+	Kilometers Unit = iota
+	// Miles distances are rounded to 2 decimals
+	Miles
+	// NauticalMiles distances are rounded to 2 decimals
+	NauticalMiles
+	// Meters distances are rounded to the meter
+	Meters
+)
+
+// String returns the name of the unit
+func (u Unit) String() string {
+	switch u {
+	case Kilometers:
+		return "Kilometers"
+	case Miles:
+		return "Miles"
+	case NauticalMiles:
+		return "NauticalMiles"
+	case Meters:
+		return "Meters"
+	}
+	return fmt.Sprintf("Unit(%d)", int(u))
+}
+
+// earthRadius returns the radius of the earth expressed in the unit
+func (u Unit) earthRadius() (float64, error) {
+	switch u {
+	case Kilometers:
+		return earthRadiusKm, nil
+	case Miles:
+		return earthRadiusMi, nil
+	case NauticalMiles:
+		return earthRadiusKm / 1.852, nil
+	case Meters:
+		return earthRadiusKm * 1000, nil
+	}
+	return 0, fmt.Errorf("zipcodes: unknown distance unit %v", u)
+}
+
+// round rounds a distance expressed in the unit to its precision
+func (u Unit) round(distance float64) float64 {
+	if u == Meters {
+		return math.Round(distance)
+	}
+	return math.Round(distance*100) / 100
+}
+
+// Distance returns the line of sight distance between two zipcodes in the given unit
+func (zc *Zipcodes) Distance(zipCodeA, zipCodeB string, unit Unit) (float64, error) {
+	radius, errUnit := unit.earthRadius()
+	if errUnit != nil {
+		return 0, errUnit
+	}
+
+	locationA, errLocA := zc.lookupWithCoordinates(zipCodeA)
+	if errLocA != nil {
+		return 0, errLocA
+	}
+
+	locationB, errLocB := zc.lookupWithCoordinates(zipCodeB)
+	if errLocB != nil {
+		return 0, errLocB
+	}
+
+	return unit.round(haversine(locationA.Lat, locationA.Lon, locationB.Lat, locationB.Lon, radius)), nil
+}
+
+// PointDistance returns the line of sight distance between two lat/lon points
+// in the given unit, rounded like Distance, without having to pass the radius
+// of the earth to DistanceBetweenPoints. It returns NaN for an unknown unit.
+func PointDistance(latitude1, longitude1, latitude2, longitude2 float64, unit Unit) float64 {
+	radius, errUnit := unit.earthRadius()
+	if errUnit != nil {
+		return math.NaN()
+	}
+	return unit.round(haversine(latitude1, longitude1, latitude2, longitude2, radius))
+}
@@ -0,0 +1,79 @@
+package zipcodes
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		Unit     Unit
+		Expected float64
+	}{
+		{Kilometers, 49.87},
+		{Miles, 30.98},
+		{NauticalMiles, 26.93},
+		{Meters, 49866},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		distance, err := zipcodesDataset.Distance("01945", "03058", c.Unit)
+		if err != nil {
+			t.Errorf("Unexpected error while calculating distance in %v %v", c.Unit, err)
+		}
+		if distance != c.Expected {
+			t.Errorf("Unexpected distance in %v. Got %v, want %v", c.Unit, distance, c.Expected)
+		}
+	}
+
+	// The named methods return the same values
+	named := map[Unit]func(string, string) (float64, error){
+		Kilometers: zipcodesDataset.DistanceInKm,
+		Miles:      zipcodesDataset.DistanceInMiles,
+		Meters:     zipcodesDataset.DistanceInMeters,
+	}
+	for unit, method := range named {
+		expected, _ := zipcodesDataset.Distance("20457", "94051", unit)
+		if distance, _ := method("20457", "94051"); distance != expected {
+			t.Errorf("Unexpected distance in %v. Got %v, want %v", unit, distance, expected)
+		}
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.Distance("01945", "03058", Unit(42))
+	if err == nil || err.Error() != "zipcodes: unknown distance unit Unit(42)" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: unknown distance unit Unit(42)")
+	}
+	_, err = zipcodesDataset.Distance("01945", "XYZ", Miles)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+func TestPointDistance(t *testing.T) {
+	cases := []struct {
+		Unit     Unit
+		Expected float64
+	}{
+		{Kilometers, 49.87},
+		{Miles, 30.98},
+		{NauticalMiles, 26.93},
+		{Meters, 49866},
+	}
+	for _, c := range cases {
+		if distance := PointDistance(51.4167, 13.9333, 51.6865, 14.5094, c.Unit); distance != c.Expected {
+			t.Errorf("Unexpected distance in %v. Got %v, want %v", c.Unit, distance, c.Expected)
+		}
+	}
+	if distance := PointDistance(51.4167, 13.9333, 51.6865, 14.5094, Miles); distance != DistanceBetweenPoints(51.4167, 13.9333, 51.6865, 14.5094, earthRadiusMi) {
+		t.Errorf("Unexpected distance. Got %v, want the same as DistanceBetweenPoints", distance)
+	}
+
+	// Failing case
+	if distance := PointDistance(51.4167, 13.9333, 51.6865, 14.5094, Unit(42)); !math.IsNaN(distance) {
+		t.Errorf("Unexpected distance for an unknown unit. Got %v, want NaN", distance)
+	}
+}
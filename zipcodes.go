@@ -4,12 +4,18 @@ package zipcodes
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -17,59 +23,349 @@ const (
 	earthRadiusMi = 3958
 )
 
+// ErrTooManyResults is returned together with the truncated list by the radius
+// queries of a dataset loaded WithMaxRadiusResults when more zipcodes matched
+var ErrTooManyResults = errors.New("zipcodes: radius query matched more zipcodes than the maximum")
+
 // ZipCodeLocation struct represents each line of the dataset
 type ZipCodeLocation struct {
-	ZipCode   string
-	PlaceName string
-	AdminName string
-	Lat       float64
-	Lon       float64
-	StateCode string
+	CountryCode string
+	ZipCode     string
+	PlaceName   string
+	AdminName   string
+	AdminName2  string
+	AdminName3  string
+	Lat         float64
+	Lon         float64
+	StateCode   string
+	Accuracy    int
+	ModifiedAt  time.Time
+}
+
+// HasCoordinates reports whether the location has a latitude and longitude.
+// It is false for rows loaded WithBlankCoordinates.
+func (l ZipCodeLocation) HasCoordinates() bool {
+	return !math.IsNaN(l.Lat) && !math.IsNaN(l.Lon)
+}
+
+// ZipCodeSummary is a compact view of a zipcode meant for API responses. Its
+// JSON form stays the same when ZipCodeLocation gains fields. Lat and Lon are
+// nil, and encoded as null, for rows loaded WithBlankCoordinates.
+type ZipCodeSummary struct {
+	ZipCode   string   `json:"zipCode"`
+	PlaceName string   `json:"placeName"`
+	StateCode string   `json:"stateCode"`
+	Lat       *float64 `json:"lat"`
+	Lon       *float64 `json:"lon"`
+}
+
+// Summary returns the compact view of the location
+func (l ZipCodeLocation) Summary() ZipCodeSummary {
+	summary := ZipCodeSummary{ZipCode: l.ZipCode, PlaceName: l.PlaceName, StateCode: l.StateCode}
+	if l.HasCoordinates() {
+		lat, lon := l.Lat, l.Lon
+		summary.Lat, summary.Lon = &lat, &lon
+	}
+	return summary
 }
 
 // Zipcodes contains the whole list of structs representing
 // the zipcode dataset
 type Zipcodes struct {
 	DatasetList map[string]ZipCodeLocation
+	source      LocationSource
+	index       *spatialIndex
+	duplicates  map[string][]ZipCodeLocation
+	placeNames  map[string][]string
+	keys        *keyIndex
+	maxResults  int
+	keyFunc     func(ZipCodeLocation) string
+}
+
+// LocationSource is the storage the query methods read zipcodes from.
+// Zipcodes is the default in-memory implementation, other stores (a database,
+// a spatial store...) can be plugged in with NewFromSource.
+type LocationSource interface {
+	// Get returns the location of a zipcode and whether it was found
+	Get(zipCode string) (ZipCodeLocation, bool)
+	// Range calls fn for every location until fn returns false
+	Range(fn func(ZipCodeLocation) bool)
 }
 
 // New loads the dataset that this packages uses and
 // returns a struct that contains the dataset as a map interface
-func New(datasetPath string) (*Zipcodes, error) {
-	zipcodes, err := LoadDataset(datasetPath)
+func New(datasetPath string, opts ...Option) (*Zipcodes, error) {
+	zipcodes, err := LoadDataset(datasetPath, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &zipcodes, nil
 }
 
+// NewStrict works like New but returns an error when no record was loaded,
+// e.g. because the file is empty or every row was filtered out by the options,
+// so a misconfigured dataset fails at startup instead of on every lookup
+func NewStrict(datasetPath string, opts ...Option) (*Zipcodes, error) {
+	zipcodes, err := New(datasetPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(zipcodes.DatasetList) == 0 {
+		return nil, fmt.Errorf("zipcodes: dataset %s has no records", datasetPath)
+	}
+	return zipcodes, nil
+}
+
+// newZipcodes returns an empty in-memory dataset
+func newZipcodes() Zipcodes {
+	return Zipcodes{DatasetList: make(map[string]ZipCodeLocation), index: &spatialIndex{}, keys: &keyIndex{}}
+}
+
+// newDataset returns an empty in-memory dataset keyed as asked by the options
+func newDataset(options loadOptions) Zipcodes {
+	zipcodes := newZipcodes()
+	zipcodes.keyFunc = options.keyFunc
+	return zipcodes
+}
+
+// NewFromSource returns a struct whose query methods read the zipcodes
+// from the given source instead of an in-memory dataset. No index is built
+// over the source: every radius, nearest or range query scans it, so queries
+// see its current data and nothing is copied into the Go heap. WarmUp opts
+// into the spatial index, which holds a copy of every row with coordinates
+// and is a snapshot refreshed only by InvalidateIndex.
+func NewFromSource(source LocationSource) *Zipcodes {
+	return &Zipcodes{source: source}
+}
+
+// NewFromPaths loads several datasets one after the other and merges them
+// into a single struct, parsing every file with the given options like New.
+// When a zipcode appears in more than one file, the entry from the last file
+// wins. A file that can not be opened fails the load with an error instead of
+// exiting the process like LoadDataset does.
+func NewFromPaths(paths []string, opts ...Option) (*Zipcodes, error) {
+	options := newLoadOptions(opts)
+	merged := newDataset(options)
+	for _, path := range paths {
+		zipcodes, err := loadPath(path, options)
+		if err != nil {
+			return nil, err
+		}
+		mergeDataset(&merged, zipcodes)
+	}
+	merged.applyIndexOptions(options)
+	return &merged, nil
+}
+
+// NewFromPathsConcurrent loads several datasets in parallel goroutines and
+// merges them with the same rules and options as NewFromPaths. If several
+// files fail, the error of the first one in the given order is returned.
+func NewFromPathsConcurrent(paths []string, opts ...Option) (*Zipcodes, error) {
+	datasets := make([]Zipcodes, len(paths))
+	errs := make([]error, len(paths))
+
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			// every goroutine gets its own options, the interned strings
+			// are not safe for concurrent use
+			datasets[i], errs[i] = loadPath(path, newLoadOptions(opts))
+		}(i, path)
+	}
+	wg.Wait()
+
+	options := newLoadOptions(opts)
+	merged := newDataset(options)
+	for i := range paths {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		mergeDataset(&merged, datasets[i])
+	}
+	merged.applyIndexOptions(options)
+	return &merged, nil
+}
+
+// mergeDataset copies every entry of src into dst, overriding existing zipcodes
+func mergeDataset(dst *Zipcodes, src Zipcodes) {
+	for zipCode, location := range src.DatasetList {
+		if rows := src.duplicates[zipCode]; len(rows) > 0 {
+			for _, row := range rows {
+				dst.add(row)
+			}
+			continue
+		}
+		dst.add(location)
+	}
+}
+
+// add stores a location in the dataset. When the key is already present
+// the new row wins, but every distinct row is kept in duplicates.
+func (zc *Zipcodes) add(location ZipCodeLocation) {
+	key := zc.keyOf(location)
+	previous, found := zc.DatasetList[key]
+	if found && !sameRow(previous, location) {
+		if zc.duplicates == nil {
+			zc.duplicates = make(map[string][]ZipCodeLocation)
+		}
+		if len(zc.duplicates[key]) == 0 {
+			zc.duplicates[key] = []ZipCodeLocation{previous}
+		}
+		zc.duplicates[key] = append(zc.duplicates[key], location)
+	}
+	zc.DatasetList[key] = location
+}
+
+// sameRow reports whether two rows hold the same values. Blank coordinates
+// are NaN and never equal, so two rows without coordinates compare their
+// other fields only.
+func sameRow(a, b ZipCodeLocation) bool {
+	if !a.HasCoordinates() && !b.HasCoordinates() {
+		a.Lat, a.Lon, b.Lat, b.Lon = 0, 0, 0, 0
+	}
+	return a == b
+}
+
+// keyOf returns the key a location is stored under, the bare zipcode unless
+// the dataset was loaded WithKeyFunc
+func (zc *Zipcodes) keyOf(location ZipCodeLocation) string {
+	if zc.keyFunc != nil {
+		return zc.keyFunc(location)
+	}
+	return location.ZipCode
+}
+
+// records returns every row loaded for a zipcode, in load order
+func (zc *Zipcodes) records(zipCode string) []ZipCodeLocation {
+	if rows := zc.duplicates[zipCode]; len(rows) > 0 {
+		return rows
+	}
+	if location, found := zc.Get(zipCode); found {
+		return []ZipCodeLocation{location}
+	}
+	return nil
+}
+
+// Get returns the location of a zipcode and whether it was found
+func (zc *Zipcodes) Get(zipCode string) (ZipCodeLocation, bool) {
+	if zc.source != nil {
+		return zc.source.Get(zipCode)
+	}
+	location, found := zc.DatasetList[zipCode]
+	return location, found
+}
+
+// Range calls fn for every location of the dataset until fn returns false
+func (zc *Zipcodes) Range(fn func(ZipCodeLocation) bool) {
+	if zc.source != nil {
+		zc.source.Range(fn)
+		return
+	}
+	for _, location := range zc.DatasetList {
+		if !fn(location) {
+			return
+		}
+	}
+}
+
+// rangeContextCheckEvery is the number of locations rangeContext visits
+// between two checks of the context
+const rangeContextCheckEvery = 1024
+
+// rangeContext calls fn for every location of the dataset like Range, but
+// stops and returns the context error once ctx is done. The context is
+// checked before the first location and then every rangeContextCheckEvery.
+func (zc *Zipcodes) rangeContext(ctx context.Context, fn func(ZipCodeLocation) bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var errCtx error
+	visited := 0
+	zc.Range(func(elm ZipCodeLocation) bool {
+		visited++
+		if visited%rangeContextCheckEvery == 0 {
+			if errCtx = ctx.Err(); errCtx != nil {
+				return false
+			}
+		}
+		return fn(elm)
+	})
+	return errCtx
+}
+
 // Lookup looks for a zipcode inside the map interface
 func (zc *Zipcodes) Lookup(zipCode string) (*ZipCodeLocation, error) {
-	foundedZipcode := zc.DatasetList[zipCode]
+	foundedZipcode, _ := zc.Get(zipCode)
 	if (foundedZipcode == ZipCodeLocation{}) {
 		return &ZipCodeLocation{}, fmt.Errorf("zipcodes: zipcode %s not found !", zipCode)
 	}
 	return &foundedZipcode, nil
 }
 
+// lookupWithCoordinates looks for a zipcode and fails if it has no coordinates
+func (zc *Zipcodes) lookupWithCoordinates(zipCode string) (*ZipCodeLocation, error) {
+	location, err := zc.Lookup(zipCode)
+	if err != nil {
+		return location, err
+	}
+	if !location.HasCoordinates() {
+		return location, fmt.Errorf("zipcodes: zipcode %s has no coordinates", zipCode)
+	}
+	return location, nil
+}
+
+// LookupContext looks for a zipcode like Lookup does, but returns the
+// context error if ctx is already done before the search starts. The lookup
+// itself is a single map or LocationSource.Get call and is not interrupted,
+// the scanning searches have their own variants such as
+// LookupNearestPrefixContext and SuggestZipcodesContext.
+func (zc *Zipcodes) LookupContext(ctx context.Context, zipCode string) (*ZipCodeLocation, error) {
+	if err := ctx.Err(); err != nil {
+		return &ZipCodeLocation{}, err
+	}
+	return zc.Lookup(zipCode)
+}
+
 // DistanceInKm returns the line of sight distance between two zipcodes in Kilometers
 func (zc *Zipcodes) DistanceInKm(zipCodeA string, zipCodeB string) (float64, error) {
-	return zc.CalculateDistance(zipCodeA, zipCodeB, earthRadiusKm)
+	return zc.Distance(zipCodeA, zipCodeB, Kilometers)
 }
 
 // DistanceInMiles returns the line of sight distance between two zipcodes in Miles
 func (zc *Zipcodes) DistanceInMiles(zipCodeA string, zipCodeB string) (float64, error) {
-	return zc.CalculateDistance(zipCodeA, zipCodeB, earthRadiusMi)
+	return zc.Distance(zipCodeA, zipCodeB, Miles)
+}
+
+// locations looks for every zipcode of the list and returns their locations
+// in the same order, or the error of the first one missing or without coordinates
+func (zc *Zipcodes) locations(zipCodes []string) ([]ZipCodeLocation, error) {
+	locations := make([]ZipCodeLocation, 0, len(zipCodes))
+	for _, zipCode := range zipCodes {
+		location, err := zc.lookupWithCoordinates(zipCode)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, *location)
+	}
+	return locations, nil
+}
+
+// DistanceInMeters returns the line of sight distance between two zipcodes in
+// Meters, rounded to the meter instead of the 10 meters of DistanceInKm
+func (zc *Zipcodes) DistanceInMeters(zipCodeA string, zipCodeB string) (float64, error) {
+	return zc.Distance(zipCodeA, zipCodeB, Meters)
 }
 
 // CalculateDistance returns the line of sight distance between two zipcodes in Kilometers
 func (zc *Zipcodes) CalculateDistance(zipCodeA string, zipCodeB string, radius float64) (float64, error) {
-	locationA, errLocA := zc.Lookup(zipCodeA)
+	locationA, errLocA := zc.lookupWithCoordinates(zipCodeA)
 	if errLocA != nil {
 		return 0, errLocA
 	}
 
-	locationB, errLocB := zc.Lookup(zipCodeB)
+	locationB, errLocB := zc.lookupWithCoordinates(zipCodeB)
 	if errLocB != nil {
 		return 0, errLocB
 	}
@@ -77,9 +373,30 @@ func (zc *Zipcodes) CalculateDistance(zipCodeA string, zipCodeB string, radius f
 	return DistanceBetweenPoints(locationA.Lat, locationA.Lon, locationB.Lat, locationB.Lon, radius), nil
 }
 
+// CompareDistances returns the distance between two zipcodes computed with
+// each of the given earth radius values, keyed by radius. It helps audit the
+// differences between radius conventions (e.g. 6371 versus 6378.137 km).
+func (zc *Zipcodes) CompareDistances(zipCodeA, zipCodeB string, radii ...float64) (map[float64]float64, error) {
+	if len(radii) == 0 {
+		return nil, fmt.Errorf("zipcodes: at least one radius is needed")
+	}
+	distances := make(map[float64]float64, len(radii))
+	for _, radius := range radii {
+		if radius <= 0 {
+			return nil, fmt.Errorf("zipcodes: radius %v must be greater than zero", radius)
+		}
+		distance, err := zc.CalculateDistance(zipCodeA, zipCodeB, radius)
+		if err != nil {
+			return nil, err
+		}
+		distances[radius] = distance
+	}
+	return distances, nil
+}
+
 // DistanceInKmToZipcode calculates the distance between a zipcode and a give lat/lon in Kilometers
 func (zc *Zipcodes) DistanceInKmToZipCode(zipCode string, latitude, longitude float64) (float64, error) {
-	location, errLoc := zc.Lookup(zipCode)
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
 	if errLoc != nil {
 		return 0, errLoc
 	}
@@ -89,7 +406,7 @@ func (zc *Zipcodes) DistanceInKmToZipCode(zipCode string, latitude, longitude fl
 
 // DistanceInMilToZipcode calculates the distance between a zipcode and a give lat/lon in Miles
 func (zc *Zipcodes) DistanceInMilToZipCode(zipCode string, latitude, longitude float64) (float64, error) {
-	location, errLoc := zc.Lookup(zipCode)
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
 	if errLoc != nil {
 		return 0, errLoc
 	}
@@ -97,45 +414,178 @@ func (zc *Zipcodes) DistanceInMilToZipCode(zipCode string, latitude, longitude f
 	return DistanceBetweenPoints(location.Lat, location.Lon, latitude, longitude, earthRadiusMi), nil
 }
 
+// ReconcileCoordinates checks that a lat/lon received together with a zipcode,
+// e.g. from an address form, is consistent with it. It reports whether the
+// point is at most toleranceKm away from the zipcode, along with the distance
+// in Kilometers as returned by DistanceInKmToZipCode.
+func (zc *Zipcodes) ReconcileCoordinates(zipCode string, lat, lon float64, toleranceKm float64) (bool, float64, error) {
+	if toleranceKm < 0 {
+		return false, 0, fmt.Errorf("zipcodes: tolerance must not be negative")
+	}
+	distance, err := zc.DistanceInKmToZipCode(zipCode, lat, lon)
+	if err != nil {
+		return false, 0, err
+	}
+	return distance <= toleranceKm, distance, nil
+}
+
 // GetZipcodesWithinKmRadius get all zipcodes within the radius of this zipcode
 func (zc *Zipcodes) GetZipcodesWithinKmRadius(zipCode string, radius float64) ([]string, error) {
 	zipcodeList := []string{}
-	location, errLoc := zc.Lookup(zipCode)
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
 	if errLoc != nil {
 		return zipcodeList, errLoc
 	}
 
-	return zc.FindZipcodesWithinRadius(location, radius, earthRadiusKm), nil
+	return zc.cappedZipcodesWithinRadius(location, radius, earthRadiusKm)
 }
 
 // GetZipcodesWithinMlRadius get all zipcodes within the radius of this zipcode
 func (zc *Zipcodes) GetZipcodesWithinMlRadius(zipCode string, radius float64) ([]string, error) {
 	zipcodeList := []string{}
-	location, errLoc := zc.Lookup(zipCode)
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
 	if errLoc != nil {
 		return zipcodeList, errLoc
 	}
 
-	return zc.FindZipcodesWithinRadius(location, radius, earthRadiusMi), nil
+	return zc.cappedZipcodesWithinRadius(location, radius, earthRadiusMi)
 }
 
-// FindZipcodesWithinRadius finds zipcodes within a given radius
-func (zc *Zipcodes) FindZipcodesWithinRadius(location *ZipCodeLocation, maxRadius float64, earthRadius float64) []string {
+// cappedZipcodesWithinRadius works like FindZipcodesWithinRadius but stops
+// collecting at the maximum number of results of the dataset, if any, and then
+// returns the zipcodes found so far with ErrTooManyResults
+func (zc *Zipcodes) cappedZipcodesWithinRadius(location *ZipCodeLocation, maxRadius float64, earthRadius float64) ([]string, error) {
 	zipcodeList := []string{}
-	for _, elm := range zc.DatasetList {
-		if elm.ZipCode != location.ZipCode {
-			distance := DistanceBetweenPoints(location.Lat, location.Lon, elm.Lat, elm.Lon, earthRadius)
-			if distance < maxRadius {
-				zipcodeList = append(zipcodeList, elm.ZipCode)
+	var err error
+	center := newPoint(*location)
+	key := zc.keyOf(*location)
+	zc.rangeNear(center, maxRadius, earthRadius, func(p point) bool {
+		if zc.keyOf(p.location) != key && center.distanceTo(p, earthRadius) < maxRadius {
+			if zc.reachedMaxResults(len(zipcodeList)) {
+				err = ErrTooManyResults
+				return false
+			}
+			zipcodeList = append(zipcodeList, zc.keyOf(p.location))
+		}
+		return true
+	})
+	return zipcodeList, err
+}
+
+// reachedMaxResults reports whether a radius query already collected the
+// maximum number of results of the dataset
+func (zc *Zipcodes) reachedMaxResults(collected int) bool {
+	return zc.maxResults > 0 && collected >= zc.maxResults
+}
+
+// ZipcodesWithinKmRadiusWhere returns the zipcodes within the radius in Kilometers
+// of this zipcode that match the predicate, sorted by zipcode. A nil predicate
+// matches every zipcode.
+func (zc *Zipcodes) ZipcodesWithinKmRadiusWhere(zipCode string, radius float64, pred func(ZipCodeLocation) bool) ([]ZipCodeLocation, error) {
+	zipcodeList := []ZipCodeLocation{}
+	location, errLoc := zc.lookupWithCoordinates(zipCode)
+	if errLoc != nil {
+		return zipcodeList, errLoc
+	}
+
+	var err error
+	center := newPoint(*location)
+	key := zc.keyOf(*location)
+	zc.rangeNear(center, radius, earthRadiusKm, func(p point) bool {
+		if zc.keyOf(p.location) != key && center.distanceTo(p, earthRadiusKm) < radius && (pred == nil || pred(p.location)) {
+			if zc.reachedMaxResults(len(zipcodeList)) {
+				err = ErrTooManyResults
+				return false
 			}
+			zipcodeList = append(zipcodeList, p.location)
 		}
+		return true
+	})
+
+	sort.Slice(zipcodeList, func(i, j int) bool {
+		return zc.keyOf(zipcodeList[i]) < zc.keyOf(zipcodeList[j])
+	})
+	return zipcodeList, err
+}
+
+// ZipcodesWithinKmRadiusByAdmin returns the zipcodes within the radius in
+// Kilometers of this zipcode grouped by their first level admin name, each
+// group sorted by zipcode, e.g. for per region coverage reports
+func (zc *Zipcodes) ZipcodesWithinKmRadiusByAdmin(zipCode string, radius float64) (map[string][]ZipCodeLocation, error) {
+	return zc.ZipcodesWithinKmRadiusGroupedBy(zipCode, radius, func(location ZipCodeLocation) string {
+		return location.AdminName
+	})
+}
+
+// ZipcodesWithinKmRadiusGroupedBy returns the zipcodes within the radius in
+// Kilometers of this zipcode grouped by the key returned for each of them,
+// e.g. AdminName3 for counties, each group sorted by zipcode. When the maximum
+// number of results is hit the groups found so far come with ErrTooManyResults.
+func (zc *Zipcodes) ZipcodesWithinKmRadiusGroupedBy(zipCode string, radius float64, key func(ZipCodeLocation) string) (map[string][]ZipCodeLocation, error) {
+	locations, err := zc.ZipcodesWithinKmRadiusWhere(zipCode, radius, nil)
+	if err != nil && err != ErrTooManyResults {
+		return nil, err
 	}
 
+	groups := make(map[string][]ZipCodeLocation)
+	for _, elm := range locations {
+		groups[key(elm)] = append(groups[key(elm)], elm)
+	}
+	return groups, err
+}
+
+// ZipcodesWithinTravelTime returns the zipcodes reachable from this zipcode in
+// the given minutes at the given speed, sorted by zipcode. It is a straight line
+// approximation: the radius is minutes × speed, roads and terrain are ignored.
+func (zc *Zipcodes) ZipcodesWithinTravelTime(zipCode string, minutes, kmPerHour float64) ([]ZipCodeLocation, error) {
+	if minutes <= 0 || kmPerHour <= 0 {
+		return []ZipCodeLocation{}, fmt.Errorf("zipcodes: travel time and speed must be greater than zero")
+	}
+	return zc.ZipcodesWithinKmRadiusWhere(zipCode, minutes/60*kmPerHour, nil)
+}
+
+// FindZipcodesWithinRadius finds zipcodes within a given radius. Unlike the
+// other radius queries, it ignores the maximum number of results of the dataset.
+func (zc *Zipcodes) FindZipcodesWithinRadius(location *ZipCodeLocation, maxRadius float64, earthRadius float64) []string {
+	zipcodeList := []string{}
+	center := newPoint(*location)
+	key := zc.keyOf(*location)
+	zc.rangeNear(center, maxRadius, earthRadius, func(p point) bool {
+		if zc.keyOf(p.location) != key && center.distanceTo(p, earthRadius) < maxRadius {
+			zipcodeList = append(zipcodeList, zc.keyOf(p.location))
+		}
+		return true
+	})
+
+	return zipcodeList
+}
+
+// ZipcodesInAnnulus returns the zipcodes farther than minKm but within maxKm
+// of the given lat/lon, sorted by zipcode. It returns an empty list when
+// minKm is not lower than maxKm.
+func (zc *Zipcodes) ZipcodesInAnnulus(lat, lon, minKm, maxKm float64) []ZipCodeLocation {
+	zipcodeList := []ZipCodeLocation{}
+	if minKm >= maxKm {
+		return zipcodeList
+	}
+	center := newPoint(ZipCodeLocation{Lat: lat, Lon: lon})
+	zc.rangeNear(center, maxKm, earthRadiusKm, func(p point) bool {
+		distance := center.distanceTo(p, earthRadiusKm)
+		if distance > minKm && distance < maxKm {
+			zipcodeList = append(zipcodeList, p.location)
+		}
+		return true
+	})
+
+	sort.Slice(zipcodeList, func(i, j int) bool {
+		return zc.keyOf(zipcodeList[i]) < zc.keyOf(zipcodeList[j])
+	})
 	return zipcodeList
 }
 
 func hsin(t float64) float64 {
-	return math.Pow(math.Sin(t/2), 2)
+	s := math.Sin(t / 2)
+	return s * s
 }
 
 // degreesToRadians converts degrees to radians
@@ -146,6 +596,63 @@ func degreesToRadians(d float64) float64 {
 // DistanceBetweenPoints returns the distance between two lat/lon
 // points using the Haversin distance formula.
 func DistanceBetweenPoints(latitude1, longitude1, latitude2, longitude2 float64, radius float64) float64 {
+	distance := haversine(latitude1, longitude1, latitude2, longitude2, radius)
+
+	return math.Round(distance*100) / 100
+}
+
+// ApproximateDistanceBetweenPoints returns the distance between two lat/lon
+// points using the equirectangular approximation, cheaper than the Haversin
+// formula and close to it for short distances (a few hundred Kilometers) away
+// from the poles. The longitude difference is taken the short way around the
+// antimeridian, so points at 179 and -179 are 2 degrees apart and not 358.
+func ApproximateDistanceBetweenPoints(latitude1, longitude1, latitude2, longitude2 float64, radius float64) float64 {
+	diffLon := normalizeLonDelta(longitude2 - longitude1)
+	x := degreesToRadians(diffLon) * math.Cos(degreesToRadians((latitude1+latitude2)/2))
+	y := degreesToRadians(latitude2 - latitude1)
+
+	return math.Round(math.Hypot(x, y)*radius*100) / 100
+}
+
+// normalizeLonDelta brings a longitude difference in degrees into [-180, 180]
+func normalizeLonDelta(d float64) float64 {
+	d = math.Mod(d+180, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d - 180
+}
+
+// point is a location with the values DistanceBetweenPoints needs precomputed,
+// so scans comparing many locations do not convert them again on every distance
+type point struct {
+	location ZipCodeLocation
+	lat      float64
+	lon      float64
+	cosLat   float64
+}
+
+// newPoint precomputes the radians and cosine of latitude of a location
+func newPoint(location ZipCodeLocation) point {
+	lat := degreesToRadians(location.Lat)
+	return point{
+		location: location,
+		lat:      lat,
+		lon:      degreesToRadians(location.Lon),
+		cosLat:   math.Cos(lat),
+	}
+}
+
+// distanceTo returns the same distance as DistanceBetweenPoints between two points
+func (p point) distanceTo(q point, radius float64) float64 {
+	a := hsin(q.lat-p.lat) + p.cosLat*q.cosLat*hsin(q.lon-p.lon)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+	return math.Round(c*radius*100) / 100
+}
+
+// haversine returns the unrounded distance between two lat/lon points
+func haversine(latitude1, longitude1, latitude2, longitude2 float64, radius float64) float64 {
 	lat1 := degreesToRadians(latitude1)
 	lon1 := degreesToRadians(longitude1)
 	lat2 := degreesToRadians(latitude2)
@@ -155,48 +662,172 @@ func DistanceBetweenPoints(latitude1, longitude1, latitude2, longitude2 float64,
 
 	a := hsin(diffLat) + math.Cos(lat1)*math.Cos(lat2)*hsin(diffLon)
 	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	distance := c * radius
 
-	return math.Round(distance*100) / 100
+	return c * radius
 }
 
 // LoadDataset reads and loads the dataset into a map interface
-func LoadDataset(datasetPath string) (Zipcodes, error) {
+func LoadDataset(datasetPath string, opts ...Option) (Zipcodes, error) {
 	file, err := os.Open(datasetPath)
 	if err != nil {
 		log.Fatal(err)
 		return Zipcodes{}, fmt.Errorf("zipcodes: error while opening file %v", err)
 	}
 	defer file.Close()
+	return loadFile(file, newLoadOptions(opts))
+}
 
-	scanner := bufio.NewScanner(file)
-	zipcodeMap := Zipcodes{DatasetList: make(map[string]ZipCodeLocation)}
+// loadPath parses the dataset at datasetPath like LoadDataset, but returns an
+// error instead of exiting when the file can not be opened. The indexes are
+// left to the caller, which builds them once over the merged datasets.
+func loadPath(datasetPath string, options loadOptions) (Zipcodes, error) {
+	file, err := os.Open(datasetPath)
+	if err != nil {
+		return Zipcodes{}, fmt.Errorf("zipcodes: error while opening file %v", err)
+	}
+	defer file.Close()
+	zipcodeMap := newDataset(options)
+	if err := readDataset(file, &zipcodeMap, options); err != nil {
+		return Zipcodes{}, err
+	}
+	return zipcodeMap, nil
+}
+
+// loadFile reads an opened dataset and builds the indexes asked by the options
+func loadFile(file *os.File, options loadOptions) (Zipcodes, error) {
+	zipcodeMap := newDataset(options)
+	if err := readDataset(file, &zipcodeMap, options); err != nil {
+		return Zipcodes{}, err
+	}
+	zipcodeMap.applyIndexOptions(options)
+	return zipcodeMap, nil
+}
+
+// applyIndexOptions builds the indexes and sets the query limits asked by the
+// options over a loaded dataset
+func (zc *Zipcodes) applyIndexOptions(options loadOptions) {
+	if options.buildIndex {
+		zc.WarmUp()
+	}
+	if options.placeNameIndex {
+		zc.buildPlaceNameIndex()
+	}
+	zc.maxResults = options.maxRadiusResults
+}
+
+// LoadDatasetFromReaders reads and loads several datasets, one per reader, into
+// a single map interface, parsing them with the given options like New. When a
+// zipcode appears in more than one reader, the entry from the last reader
+// wins. Errors name the index of the failing reader.
+func LoadDatasetFromReaders(readers []io.Reader, opts ...Option) (Zipcodes, error) {
+	options := newLoadOptions(opts)
+	zipcodeMap := newDataset(options)
+	for i, r := range readers {
+		if err := readDataset(r, &zipcodeMap, options); err != nil {
+			return Zipcodes{}, fmt.Errorf("%v (reader %d)", err, i)
+		}
+	}
+	zipcodeMap.applyIndexOptions(options)
+	return zipcodeMap, nil
+}
+
+// readDataset parses every line of r and adds it to the dataset
+func readDataset(r io.Reader, zipcodes *Zipcodes, options loadOptions) error {
+	scanner := options.newScanner(r)
 	for scanner.Scan() {
-		splittedLine := strings.Split(scanner.Text(), "\t")
-		if len(splittedLine) != 12 {
-			return Zipcodes{}, fmt.Errorf("zipcodes: file line does not have 12 fields")
+		location, errLine := parseLine(scanner.Text(), options)
+		if errLine != nil {
+			return errLine
+		}
+		if location.Accuracy < options.minAccuracy {
+			continue
 		}
-		lat, errLat := strconv.ParseFloat(splittedLine[9], 64)
+		zipcodes.add(location)
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("zipcodes: dataset line longer than %d bytes, see WithMaxLineLength", options.lineLengthLimit())
+		}
+		return fmt.Errorf("zipcodes: error while opening file %v", err)
+	}
+	return nil
+}
+
+// parseLine converts a tab separated dataset line into a ZipCodeLocation
+func parseLine(line string, options loadOptions) (ZipCodeLocation, error) {
+	splittedLine := strings.Split(line, "\t")
+	modifiedAt, errFields := parseModifiedAt(splittedLine, options)
+	if errFields != nil {
+		return ZipCodeLocation{}, errFields
+	}
+
+	lat, lon := math.NaN(), math.NaN()
+	blankCoordinates := strings.TrimSpace(splittedLine[9]) == "" || strings.TrimSpace(splittedLine[10]) == ""
+	if !options.allowBlankCoordinates || !blankCoordinates {
+		var errLat, errLon error
+		lat, errLat = strconv.ParseFloat(splittedLine[9], 64)
 		if errLat != nil {
-			return Zipcodes{}, fmt.Errorf("zipcodes: error while converting %s to Latitude", splittedLine[9])
+			return ZipCodeLocation{}, fmt.Errorf("zipcodes: error while converting %s to Latitude", splittedLine[9])
 		}
-		lon, errLon := strconv.ParseFloat(splittedLine[10], 64)
+		lon, errLon = strconv.ParseFloat(splittedLine[10], 64)
 		if errLon != nil {
-			return Zipcodes{}, fmt.Errorf("zipcodes: error while converting %s to Longitude", splittedLine[10])
+			return ZipCodeLocation{}, fmt.Errorf("zipcodes: error while converting %s to Longitude", splittedLine[10])
 		}
+	}
 
-		zipcodeMap.DatasetList[splittedLine[1]] = ZipCodeLocation{
-			ZipCode:   splittedLine[1],
-			PlaceName: splittedLine[2],
-			AdminName: splittedLine[3],
-			Lat:       lat,
-			Lon:       lon,
-			StateCode: splittedLine[4],
+	accuracy := 0
+	if strings.TrimSpace(splittedLine[11]) != "" {
+		var errAccuracy error
+		accuracy, errAccuracy = strconv.Atoi(strings.TrimSpace(splittedLine[11]))
+		if errAccuracy != nil {
+			return ZipCodeLocation{}, fmt.Errorf("zipcodes: error while converting %s to Accuracy", splittedLine[11])
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return Zipcodes{}, fmt.Errorf("zipcodes: error while opening file %v", err)
+	return ZipCodeLocation{
+		CountryCode: options.intern(splittedLine[0]),
+		ZipCode:     options.detach(splittedLine[1]),
+		PlaceName:   options.intern(splittedLine[2]),
+		AdminName:   options.intern(splittedLine[3]),
+		AdminName2:  options.intern(splittedLine[5]),
+		AdminName3:  options.intern(splittedLine[7]),
+		Lat:         lat,
+		Lon:         lon,
+		StateCode:   options.intern(splittedLine[4]),
+		Accuracy:    accuracy,
+		ModifiedAt:  modifiedAt,
+	}, nil
+}
+
+// parseModifiedAt checks the number of fields of a line and returns its
+// modification date. Lines have the 12 standard fields, files loaded
+// WithModifiedAtColumn may also extend up to the date column.
+func parseModifiedAt(splittedLine []string, options loadOptions) (time.Time, error) {
+	column := options.modifiedAtColumn
+	if column == 0 {
+		if len(splittedLine) != 12 {
+			return time.Time{}, fmt.Errorf("zipcodes: file line does not have 12 fields")
+		}
+		return time.Time{}, nil
 	}
-	return zipcodeMap, nil
+	if column < 12 {
+		return time.Time{}, fmt.Errorf("zipcodes: modification date column %d overlaps the standard columns", column)
+	}
+	if len(splittedLine) == 12 {
+		return time.Time{}, nil
+	}
+	if len(splittedLine) != column+1 {
+		return time.Time{}, fmt.Errorf("zipcodes: file line does not have 12 or %d fields", column+1)
+	}
+
+	value := strings.TrimSpace(splittedLine[column])
+	if value == "" {
+		return time.Time{}, nil
+	}
+	modifiedAt, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("zipcodes: error while converting %s to ModifiedAt", splittedLine[column])
+	}
+	return modifiedAt, nil
 }
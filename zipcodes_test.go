@@ -1,7 +1,14 @@
 package zipcodes
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"math/rand"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -15,6 +22,113 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewStrict(t *testing.T) {
+	zipcodesDataset, err := NewStrict("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if len(zipcodesDataset.DatasetList) != 8 {
+		t.Errorf("Unexpected dataset size. Got %d, want %d", len(zipcodesDataset.DatasetList), 8)
+	}
+
+	// New accepts an empty dataset
+	zipcodesDataset, err = New("datasets/empty_dataset.txt")
+	if err != nil || len(zipcodesDataset.DatasetList) != 0 {
+		t.Errorf("Unexpected result while loading an empty dataset. Got %v %v", zipcodesDataset, err)
+	}
+
+	// Failing cases
+	_, err = NewStrict("datasets/empty_dataset.txt")
+	if err == nil || err.Error() != "zipcodes: dataset datasets/empty_dataset.txt has no records" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: dataset datasets/empty_dataset.txt has no records")
+	}
+	_, err = NewStrict("datasets/accuracy_dataset.txt", WithMinAccuracy(7))
+	if err == nil || err.Error() != "zipcodes: dataset datasets/accuracy_dataset.txt has no records" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: dataset datasets/accuracy_dataset.txt has no records")
+	}
+	_, err = NewStrict("datasets/wrong_lat_dataset.txt")
+	if err == nil {
+		t.Errorf("Expected an error while loading an invalid dataset")
+	}
+}
+
+func TestNewFromPaths(t *testing.T) {
+	_, err := NewFromPaths([]string{"datasets/valid_dataset.txt", "datasets/inspect_dataset.txt"})
+	if err == nil {
+		t.Errorf("Expected an error while loading an invalid dataset")
+	}
+
+	zipcodesDataset, err := NewFromPaths([]string{"datasets/valid_dataset.txt", "datasets/overlay_dataset.txt"})
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if len(zipcodesDataset.DatasetList) != 9 {
+		t.Errorf("Unexpected dataset size. Got %d, want %d", len(zipcodesDataset.DatasetList), 9)
+	}
+	// The last file wins for repeated zipcodes
+	if zipcodesDataset.DatasetList["01945"].PlaceName != "Guteborn Overlay" {
+		t.Errorf("Unexpected place name. Got %s, want %s", zipcodesDataset.DatasetList["01945"].PlaceName, "Guteborn Overlay")
+	}
+
+	// The options apply to every file and to the merged struct
+	keyedDataset, err := NewFromPaths([]string{"datasets/duplicates_dataset.txt", "datasets/blank_coordinates_dataset.txt"},
+		WithBlankCoordinates(), WithSpatialIndex(), WithKeyFunc(func(location ZipCodeLocation) string {
+			return location.CountryCode + "-" + location.ZipCode
+		}))
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if len(keyedDataset.DatasetList) != 6 || !keyedDataset.IndexReady() {
+		t.Errorf("Unexpected keyed dataset. Got %d rows and index ready %v, want 6 and true", len(keyedDataset.DatasetList), keyedDataset.IndexReady())
+	}
+	if location, err := keyedDataset.Lookup("FR-10000"); err != nil || location.PlaceName != "Troyes" {
+		t.Errorf("Unexpected location for FR-10000. Got %v %v, want Troyes", location, err)
+	}
+
+	// A missing file returns an error instead of exiting
+	_, err = NewFromPaths([]string{"datasets/valid_dataset.txt", "datasets/missing_dataset.txt"})
+	if err == nil || err.Error() != "zipcodes: error while opening file open datasets/missing_dataset.txt: no such file or directory" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: error while opening file open datasets/missing_dataset.txt: no such file or directory")
+	}
+}
+
+func TestNewFromPathsConcurrent(t *testing.T) {
+	paths := []string{"datasets/valid_dataset.txt", "datasets/overlay_dataset.txt"}
+	serial, err := NewFromPaths(paths)
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	parallel, err := NewFromPathsConcurrent(paths)
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if reflect.DeepEqual(serial.DatasetList, parallel.DatasetList) != true {
+		t.Errorf("Parallel and serial loads returned different datasets")
+	}
+	blankPaths := []string{"datasets/valid_dataset.txt", "datasets/blank_coordinates_dataset.txt"}
+	serial, err = NewFromPaths(blankPaths, WithBlankCoordinates(), WithStringInterning())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	parallel, err = NewFromPathsConcurrent(blankPaths, WithBlankCoordinates(), WithStringInterning())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if len(parallel.DatasetList) != len(serial.DatasetList) || !parallel.DatasetList["20457"].HasCoordinates() {
+		t.Errorf("Parallel and serial loads with options returned different datasets")
+	}
+
+	// Failing case, the error of the first failing file is returned
+	_, err = NewFromPathsConcurrent([]string{"datasets/valid_dataset.txt", "datasets/wrong_lat_dataset.txt", "datasets/wrong_lon_dataset.txt"})
+	if err == nil || err.Error() != "zipcodes: error while converting WRONG to Latitude" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: error while converting WRONG to Latitude")
+	}
+	_, err = NewFromPathsConcurrent([]string{"datasets/valid_dataset.txt", "datasets/missing_dataset.txt", "datasets/wrong_lat_dataset.txt"})
+	if err == nil || err.Error() != "zipcodes: error while opening file open datasets/missing_dataset.txt: no such file or directory" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: error while opening file open datasets/missing_dataset.txt: no such file or directory")
+	}
+}
+
 func TestLoadDataset(t *testing.T) {
 	// Wrong file format cases
 	cases := []struct {
@@ -52,6 +166,111 @@ func TestLoadDataset(t *testing.T) {
 	}
 }
 
+func TestLoadDatasetWithBlankCoordinates(t *testing.T) {
+	// Blank coordinates fail by default
+	_, err := LoadDataset("datasets/blank_coordinates_dataset.txt")
+	if err == nil || err.Error() != "zipcodes: error while converting  to Latitude" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: error while converting  to Latitude")
+	}
+
+	zipcodesDataset, err := New("datasets/blank_coordinates_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	location, err := zipcodesDataset.Lookup("96799")
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if location.HasCoordinates() {
+		t.Errorf("Expected zipcode %s to have no coordinates", location.ZipCode)
+	}
+
+	_, err = zipcodesDataset.DistanceInKm("01945", "96799")
+	if err == nil || err.Error() != "zipcodes: zipcode 96799 has no coordinates" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 96799 has no coordinates")
+	}
+
+	zcList, err := zipcodesDataset.GetZipcodesWithinKmRadius("01945", 20000)
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if reflect.DeepEqual(zcList, []string{"03058"}) != true {
+		t.Errorf("Unexpected zipcode list returned. Got %v", zcList)
+	}
+}
+
+func TestLoadDatasetFromReaders(t *testing.T) {
+	valid, err := os.Open("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error while opening dataset %v", err)
+	}
+	defer valid.Close()
+	overlay := strings.NewReader("DE\t01945\tGuteborn Overlay\tBrandenburg\tBB\t\t00\tLandkreis Oberspreewald-Lausitz\t12066\t51.4167\t13.9333\t4\n")
+
+	dataset, err := LoadDatasetFromReaders([]io.Reader{valid, overlay})
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if len(dataset.DatasetList) != 8 {
+		t.Errorf("Unexpected dataset size. Got %d, want %d", len(dataset.DatasetList), 8)
+	}
+	if dataset.DatasetList["01945"].PlaceName != "Guteborn Overlay" {
+		t.Errorf("Unexpected place name. Got %s, want %s", dataset.DatasetList["01945"].PlaceName, "Guteborn Overlay")
+	}
+
+	// The options apply to every reader
+	blank, err := os.Open("datasets/blank_coordinates_dataset.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error while opening dataset %v", err)
+	}
+	defer blank.Close()
+	blankDataset, err := LoadDatasetFromReaders([]io.Reader{blank}, WithBlankCoordinates(), WithSpatialIndex())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	if len(blankDataset.DatasetList) != 3 || !blankDataset.IndexReady() {
+		t.Errorf("Unexpected dataset. Got %d rows and index ready %v, want 3 and true", len(blankDataset.DatasetList), blankDataset.IndexReady())
+	}
+
+	// Failing case, the error names the failing reader
+	wrong, err := os.Open("datasets/wrong_lat_dataset.txt")
+	if err != nil {
+		t.Fatalf("Unexpected error while opening dataset %v", err)
+	}
+	defer wrong.Close()
+	_, err = LoadDatasetFromReaders([]io.Reader{strings.NewReader(""), wrong})
+	if err == nil || err.Error() != "zipcodes: error while converting WRONG to Latitude (reader 1)" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: error while converting WRONG to Latitude (reader 1)")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	zipcodesDataset, err := New("datasets/blank_coordinates_dataset.txt", WithBlankCoordinates())
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	cases := []struct {
+		ZipCode      string
+		ExpectedJSON string
+	}{
+		{"01945", `{"zipCode":"01945","placeName":"Guteborn","stateCode":"BB","lat":51.4167,"lon":13.9333}`},
+		{"96799", `{"zipCode":"96799","placeName":"Pago Pago","stateCode":"AS","lat":null,"lon":null}`},
+	}
+	for _, c := range cases {
+		location, err := zipcodesDataset.Lookup(c.ZipCode)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s %v", c.ZipCode, err)
+		}
+		encoded, err := json.Marshal(location.Summary())
+		if err != nil {
+			t.Errorf("Unexpected error while encoding summary %v", err)
+		}
+		if string(encoded) != c.ExpectedJSON {
+			t.Errorf("Unexpected summary for %s. Got %s, want %s", c.ZipCode, encoded, c.ExpectedJSON)
+		}
+	}
+}
+
 func TestLookup(t *testing.T) {
 	zipcodesDataset, err := New("datasets/valid_dataset.txt")
 	if err != nil {
@@ -65,12 +284,15 @@ func TestLookup(t *testing.T) {
 		t.Errorf("Unexpected error while looking for zipcode %s", existingZipCode)
 	}
 	expectedZipCode := ZipCodeLocation{
-		ZipCode:   "01945",
-		PlaceName: "Guteborn",
-		AdminName: "Brandenburg",
-		Lat:       51.4167,
-		Lon:       13.9333,
-		StateCode: "BB",
+		CountryCode: "DE",
+		ZipCode:     "01945",
+		PlaceName:   "Guteborn",
+		AdminName:   "Brandenburg",
+		AdminName3:  "Landkreis Oberspreewald-Lausitz",
+		Lat:         51.4167,
+		Lon:         13.9333,
+		StateCode:   "BB",
+		Accuracy:    4,
 	}
 
 	if reflect.DeepEqual(foundedZC, &expectedZipCode) != true {
@@ -84,6 +306,30 @@ func TestLookup(t *testing.T) {
 	}
 }
 
+func TestLookupContext(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	// Looking for a zipcode with a live context
+	foundedZC, err := zipcodesDataset.LookupContext(context.Background(), "01945")
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if foundedZC.ZipCode != "01945" {
+		t.Errorf("Unexpected zipcode returned. Got %s, want %s", foundedZC.ZipCode, "01945")
+	}
+
+	// Looking for a zipcode with a cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, errCtx := zipcodesDataset.LookupContext(ctx, "01945")
+	if errCtx != context.Canceled {
+		t.Errorf("Unexpected error. Got %v, want %v", errCtx, context.Canceled)
+	}
+}
+
 func TestDistanceBetweenPoints(t *testing.T) {
 	cases := []struct {
 		coordsA    []float64
@@ -115,6 +361,103 @@ func TestDistanceBetweenPoints(t *testing.T) {
 	}
 }
 
+func TestApproximateDistanceBetweenPoints(t *testing.T) {
+	cases := []struct {
+		coordsA []float64
+		coordsB []float64
+	}{
+		{
+			[]float64{52.520008, 13.404954}, // Berlin
+			[]float64{51.217941, 6.761680},  // Düsseldorf
+		},
+		{
+			[]float64{53.5497, 9.9794}, // Hamburg Neustadt
+			[]float64{53.605, 9.9161},  // Hamburg Eidelstedt
+		},
+		{
+			[]float64{-16.8333, 179.9667}, // Fiji
+			[]float64{-16.8333, -179.9667},
+		},
+		{
+			[]float64{0, 179},
+			[]float64{0, -179},
+		},
+		{
+			[]float64{10, -179.5},
+			[]float64{10.5, 179.5},
+		},
+	}
+
+	for _, c := range cases {
+		exact := DistanceBetweenPoints(c.coordsA[0], c.coordsA[1], c.coordsB[0], c.coordsB[1], earthRadiusKm)
+		for _, kms := range []float64{
+			ApproximateDistanceBetweenPoints(c.coordsA[0], c.coordsA[1], c.coordsB[0], c.coordsB[1], earthRadiusKm),
+			ApproximateDistanceBetweenPoints(c.coordsB[0], c.coordsB[1], c.coordsA[0], c.coordsA[1], earthRadiusKm),
+		} {
+			// within 0.5% of the Haversin distance for these short distances
+			if math.Abs(kms-exact) > exact*0.005 {
+				t.Errorf("Approximate distance between %v and %v is too far from %v. Got %v", c.coordsA, c.coordsB, exact, kms)
+			}
+		}
+	}
+
+	if kms := ApproximateDistanceBetweenPoints(0, 179, 0, -179, earthRadiusKm); kms != 222.39 {
+		t.Errorf("Distance across the antimeridian does not match. Expected %v, got %v", 222.39, kms)
+	}
+}
+
+// referenceDistance is the Haversine formula as originally written, used to
+// check that the optimized versions return the very same values
+func referenceDistance(latitude1, longitude1, latitude2, longitude2 float64, radius float64) float64 {
+	hsin := func(t float64) float64 {
+		return math.Pow(math.Sin(t/2), 2)
+	}
+	lat1 := latitude1 * math.Pi / 180
+	lon1 := longitude1 * math.Pi / 180
+	lat2 := latitude2 * math.Pi / 180
+	lon2 := longitude2 * math.Pi / 180
+
+	a := hsin(lat2-lat1) + math.Cos(lat1)*math.Cos(lat2)*hsin(lon2-lon1)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return math.Round(c*radius*100) / 100
+}
+
+func TestPointDistanceMatchesReference(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 10000; i++ {
+		a := ZipCodeLocation{Lat: r.Float64()*180 - 90, Lon: r.Float64()*360 - 180}
+		b := ZipCodeLocation{Lat: r.Float64()*180 - 90, Lon: r.Float64()*360 - 180}
+		if i%2 == 0 {
+			// Close points, as in radius queries
+			b = ZipCodeLocation{Lat: a.Lat + r.Float64() - 0.5, Lon: a.Lon + r.Float64() - 0.5}
+		}
+		for _, radius := range []float64{earthRadiusKm, earthRadiusMi} {
+			want := referenceDistance(a.Lat, a.Lon, b.Lat, b.Lon, radius)
+			if got := DistanceBetweenPoints(a.Lat, a.Lon, b.Lat, b.Lon, radius); got != want {
+				t.Errorf("DistanceBetweenPoints(%v, %v) = %v, want %v", a, b, got, want)
+			}
+			if got := newPoint(a).distanceTo(newPoint(b), radius); got != want {
+				t.Errorf("distanceTo(%v, %v) = %v, want %v", a, b, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkDistanceBetweenPoints(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		DistanceBetweenPoints(52.520008, 13.404954, 51.217941, 6.761680, earthRadiusKm)
+	}
+}
+
+func BenchmarkPointDistance(b *testing.B) {
+	berlin := newPoint(ZipCodeLocation{Lat: 52.520008, Lon: 13.404954})
+	dusseldorf := newPoint(ZipCodeLocation{Lat: 51.217941, Lon: 6.761680})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		berlin.distanceTo(dusseldorf, earthRadiusKm)
+	}
+}
+
 func TestCalculateDistance(t *testing.T) {
 	// Testing valid cases where the postal code exists
 	cases := []struct {
@@ -185,6 +528,43 @@ func TestCalculateDistance(t *testing.T) {
 	}
 }
 
+func TestCompareDistances(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	distances, err := zipcodesDataset.CompareDistances("01945", "03058", earthRadiusKm, 6378.137, earthRadiusMi)
+	if err != nil {
+		t.Errorf("Unexpected error while comparing distances %v", err)
+	}
+	expected := map[float64]float64{
+		earthRadiusKm: 49.87,
+		6378.137:      49.92,
+		earthRadiusMi: 30.98,
+	}
+	if reflect.DeepEqual(distances, expected) != true {
+		t.Errorf("Unexpected distances. Got %v, want %v", distances, expected)
+	}
+
+	// Failing cases
+	fail := []struct {
+		ZipCodeA    string
+		Radii       []float64
+		ExpectedErr string
+	}{
+		{"01945", []float64{}, "zipcodes: at least one radius is needed"},
+		{"01945", []float64{earthRadiusKm, -1}, "zipcodes: radius -1 must be greater than zero"},
+		{"XYZ", []float64{earthRadiusKm}, "zipcodes: zipcode XYZ not found !"},
+	}
+	for _, c := range fail {
+		_, err := zipcodesDataset.CompareDistances(c.ZipCodeA, "03058", c.Radii...)
+		if err == nil || err.Error() != c.ExpectedErr {
+			t.Errorf("Unexpected error. Got %v, want %s", err, c.ExpectedErr)
+		}
+	}
+}
+
 func TestDistanceInKm(t *testing.T) {
 	cases := []struct {
 		ZipCodeA   string
@@ -263,6 +643,50 @@ func TestDistanceInMiles(t *testing.T) {
 	}
 }
 
+func TestDistanceInMeters(t *testing.T) {
+	cases := []struct {
+		ZipCodeA  string
+		ZipCodeB  string
+		ExpectedM float64
+	}{
+		{
+			"01945",
+			"03058",
+			49866,
+		},
+		{
+			"20457",
+			"22525",
+			7435,
+		},
+		{
+			"01945",
+			"01945",
+			0,
+		},
+	}
+
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+
+	for _, c := range cases {
+		meters, err := zipcodesDataset.DistanceInMeters(c.ZipCodeA, c.ZipCodeB)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s", err)
+		}
+		if meters != c.ExpectedM {
+			t.Errorf("Distance does not match. Expected %v, got %v", c.ExpectedM, meters)
+		}
+	}
+
+	_, err = zipcodesDataset.DistanceInMeters("01945", "11111")
+	if err == nil || err.Error() != "zipcodes: zipcode 11111 not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode 11111 not found !")
+	}
+}
+
 func TestDistanceInKmToZipCode(t *testing.T) {
 	cases := []struct {
 		ZipCode          string
@@ -341,6 +765,46 @@ func TestDistanceInMilToZipCode(t *testing.T) {
 	}
 }
 
+func TestReconcileCoordinates(t *testing.T) {
+	cases := []struct {
+		ZipCode          string
+		Latitude         float64
+		Longitude        float64
+		ToleranceKm      float64
+		ExpectedMatch    bool
+		ExpectedDistance float64
+	}{
+		{"01945", 51.4267, 13.9333, 2, true, 1.11},
+		{"01945", 51.4267, 13.9333, 1.11, true, 1.11},
+		{"01945", 51.4267, 13.9333, 1, false, 1.11},
+		{"01945", 51.4167, 13.9333, 0, true, 0},
+		{"01945", 53.5497, 9.9794, 10, false, 357.59},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		match, distance, err := zipcodesDataset.ReconcileCoordinates(c.ZipCode, c.Latitude, c.Longitude, c.ToleranceKm)
+		if err != nil {
+			t.Errorf("Unexpected error while reconciling coordinates %v", err)
+		}
+		if match != c.ExpectedMatch || distance != c.ExpectedDistance {
+			t.Errorf("Unexpected result for %v/%v within %v. Got %v %v, want %v %v", c.Latitude, c.Longitude, c.ToleranceKm, match, distance, c.ExpectedMatch, c.ExpectedDistance)
+		}
+	}
+
+	// Failing cases
+	_, _, err = zipcodesDataset.ReconcileCoordinates("01945", 51.4267, 13.9333, -1)
+	if err == nil || err.Error() != "zipcodes: tolerance must not be negative" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: tolerance must not be negative")
+	}
+	_, _, err = zipcodesDataset.ReconcileCoordinates("XYZ", 51.4267, 13.9333, 1)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
 func TestGetZipcodesWithinKmRadius(t *testing.T) {
 	cases := []struct {
 		ZipCode          string
@@ -407,6 +871,162 @@ func TestGetZipcodesWithinMlRadius(t *testing.T) {
 	}
 }
 
+func TestZipcodesWithinKmRadiusWhere(t *testing.T) {
+	inBavaria := func(location ZipCodeLocation) bool {
+		return location.StateCode == "BY"
+	}
+	cases := []struct {
+		ZipCode      string
+		Radius       float64
+		Predicate    func(ZipCodeLocation) bool
+		ExpectedList []string
+	}{
+		{
+			"01945",
+			400,
+			nil,
+			[]string{"03058", "19053", "20457", "22525", "34134", "94051"},
+		},
+		{
+			"01945",
+			400,
+			inBavaria,
+			[]string{"94051"},
+		},
+		{
+			"01945",
+			10,
+			inBavaria,
+			[]string{},
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		locations, err := zipcodesDataset.ZipcodesWithinKmRadiusWhere(c.ZipCode, c.Radius, c.Predicate)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s", err)
+		}
+		list := []string{}
+		for _, elm := range locations {
+			list = append(list, elm.ZipCode)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("Unexpected zipcode list returned. Got %v, want %v", list, c.ExpectedList)
+		}
+	}
+
+	_, err = zipcodesDataset.ZipcodesWithinKmRadiusWhere("XYZ", 10, nil)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+}
+
+// groupedZipCodes keeps only the zipcodes of grouped locations
+func groupedZipCodes(groups map[string][]ZipCodeLocation) map[string][]string {
+	zipCodes := make(map[string][]string)
+	for key, locations := range groups {
+		for _, elm := range locations {
+			zipCodes[key] = append(zipCodes[key], elm.ZipCode)
+		}
+	}
+	return zipCodes
+}
+
+func TestZipcodesWithinKmRadiusByAdmin(t *testing.T) {
+	cases := []struct {
+		Radius   float64
+		Expected map[string][]string
+	}{
+		{100, map[string][]string{"Hamburg": {"22525"}, "Mecklenburg-Vorpommern": {"19053"}}},
+		{400, map[string][]string{
+			"Brandenburg":            {"01945", "03058"},
+			"Hamburg":                {"22525"},
+			"Hessen":                 {"34134"},
+			"Mecklenburg-Vorpommern": {"19053"},
+		}},
+		{1, map[string][]string{}},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		groups, err := zipcodesDataset.ZipcodesWithinKmRadiusByAdmin("20457", c.Radius)
+		if err != nil {
+			t.Errorf("Unexpected error while grouping zipcodes %v", err)
+		}
+		if grouped := groupedZipCodes(groups); reflect.DeepEqual(grouped, c.Expected) != true {
+			t.Errorf("Unexpected groups within %v. Got %v, want %v", c.Radius, grouped, c.Expected)
+		}
+	}
+
+	groups, err := zipcodesDataset.ZipcodesWithinKmRadiusGroupedBy("01945", 400, func(location ZipCodeLocation) string {
+		return location.AdminName3
+	})
+	expected := map[string][]string{
+		"Hamburg, Freie und Hansestadt": {"20457", "22525"},
+		"Kassel, documenta-Stadt":       {"34134"},
+		"Landkreis Passau":              {"94051"},
+		"Landkreis Spree-Neiße":         {"03058"},
+		"Schwerin":                      {"19053"},
+	}
+	if grouped := groupedZipCodes(groups); err != nil || reflect.DeepEqual(grouped, expected) != true {
+		t.Errorf("Unexpected groups by county. Got %v %v, want %v", grouped, err, expected)
+	}
+
+	// Failing cases
+	_, err = zipcodesDataset.ZipcodesWithinKmRadiusByAdmin("XYZ", 100)
+	if err == nil || err.Error() != "zipcodes: zipcode XYZ not found !" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: zipcode XYZ not found !")
+	}
+	capped, err := New("datasets/valid_dataset.txt", WithMaxRadiusResults(1))
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	groups, err = capped.ZipcodesWithinKmRadiusByAdmin("20457", 400)
+	if err != ErrTooManyResults || len(groupedZipCodes(groups)) != 1 {
+		t.Errorf("Unexpected result. Got %v %v, want a single group and %v", groups, err, ErrTooManyResults)
+	}
+}
+
+func TestZipcodesWithinTravelTime(t *testing.T) {
+	cases := []struct {
+		ZipCode      string
+		Minutes      float64
+		KmPerHour    float64
+		ExpectedList []string
+	}{
+		{"01945", 35, 90, []string{"03058"}},
+		{"01945", 30, 60, []string{}},
+		{"20457", 10, 60, []string{"22525"}},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		locations, err := zipcodesDataset.ZipcodesWithinTravelTime(c.ZipCode, c.Minutes, c.KmPerHour)
+		if err != nil {
+			t.Errorf("Unexpected error while looking for zipcode %s", err)
+		}
+		list := []string{}
+		for _, elm := range locations {
+			list = append(list, elm.ZipCode)
+		}
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("Unexpected zipcode list returned. Got %v, want %v", list, c.ExpectedList)
+		}
+	}
+
+	_, err = zipcodesDataset.ZipcodesWithinTravelTime("01945", 0, 60)
+	if err == nil || err.Error() != "zipcodes: travel time and speed must be greater than zero" {
+		t.Errorf("Unexpected error. Got %v, want %s", err, "zipcodes: travel time and speed must be greater than zero")
+	}
+}
+
 func TestFindZipcodesWithinRadius(t *testing.T) {
 	cases := []struct {
 		Location     *ZipCodeLocation
@@ -451,3 +1071,114 @@ func TestFindZipcodesWithinRadius(t *testing.T) {
 		}
 	}
 }
+
+func TestZipcodesInAnnulus(t *testing.T) {
+	cases := []struct {
+		MinKm        float64
+		MaxKm        float64
+		ExpectedList []string
+	}{
+		{
+			0,
+			10,
+			[]string{},
+		},
+		{
+			10,
+			100,
+			[]string{"03058"},
+		},
+		{
+			200,
+			350,
+			[]string{"19053", "34134", "94051"},
+		},
+		{
+			100,
+			10,
+			[]string{},
+		},
+	}
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	for _, c := range cases {
+		list := []string{}
+		for _, elm := range zipcodesDataset.ZipcodesInAnnulus(51.4167, 13.9333, c.MinKm, c.MaxKm) {
+			list = append(list, elm.ZipCode)
+		}
+
+		if reflect.DeepEqual(list, c.ExpectedList) != true {
+			t.Errorf("ZipcodesInAnnulus returned an unexpected zipcode list. Got %v, want %v", list, c.ExpectedList)
+		}
+	}
+}
+
+// sliceSource is a LocationSource backed by a slice, used to check that the
+// query methods do not depend on the in-memory map
+type sliceSource []ZipCodeLocation
+
+func (s sliceSource) Get(zipCode string) (ZipCodeLocation, bool) {
+	for _, location := range s {
+		if location.ZipCode == zipCode {
+			return location, true
+		}
+	}
+	return ZipCodeLocation{}, false
+}
+
+func (s sliceSource) Range(fn func(ZipCodeLocation) bool) {
+	for _, location := range s {
+		if !fn(location) {
+			return
+		}
+	}
+}
+
+func TestNewFromSource(t *testing.T) {
+	zipcodesDataset, err := New("datasets/valid_dataset.txt")
+	if err != nil {
+		t.Errorf("Unexpected error while initializing struct %v", err)
+	}
+	source := sliceSource{}
+	zipcodesDataset.Range(func(location ZipCodeLocation) bool {
+		source = append(source, location)
+		return true
+	})
+	customDataset := NewFromSource(source)
+
+	location, err := customDataset.Lookup("01945")
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if location.PlaceName != "Guteborn" {
+		t.Errorf("Unexpected place name. Got %s, want %s", location.PlaceName, "Guteborn")
+	}
+
+	kms, err := customDataset.DistanceInKm("01945", "03058")
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if kms != 49.87 {
+		t.Errorf("Distance does not match. Expected %v, got %v", 49.87, kms)
+	}
+
+	zcList, err := customDataset.GetZipcodesWithinKmRadius("01945", 50)
+	if err != nil {
+		t.Errorf("Unexpected error while looking for zipcode %s", err)
+	}
+	if reflect.DeepEqual(zcList, []string{"03058"}) != true {
+		t.Errorf("Unexpected zipcode list returned. Got %v", zcList)
+	}
+
+	// Range stops as soon as fn returns false
+	visited := 0
+	customDataset.Range(func(location ZipCodeLocation) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("Range did not stop. Visited %d locations", visited)
+	}
+}